@@ -0,0 +1,58 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"syscall"
+	"unsafe"
+
+	"gwctl/internal/altab"
+	"gwctl/internal/target"
+)
+
+var (
+	modUser32      = syscall.NewLazyDLL("user32.dll")
+	procFindWindow = modUser32.NewProc("FindWindowW")
+)
+
+func findWindow(windowName *uint16) (syscall.Handle, error) {
+	ret, _, err := procFindWindow.Call(uintptr(unsafe.Pointer(nil)), uintptr(unsafe.Pointer(windowName)))
+	if ret == 0 {
+		return 0, err
+	}
+	return syscall.Handle(ret), nil
+}
+
+func main() {
+	var windowTitle string
+	var targetFlags target.Flags
+	var verbose bool
+	flag.StringVar(&windowTitle, "title", "", "Window title to check")
+	targetFlags.Register()
+	flag.BoolVar(&verbose, "verbose", false, "Also print the style bits the result is based on")
+	flag.Parse()
+
+	hwnd, err := target.Window(windowTitle, targetFlags, findWindow)
+	if err != nil {
+		fmt.Println("Error finding window:", err)
+		os.Exit(1)
+	}
+
+	style, err := altab.Window(hwnd).ExStyle()
+	if err != nil {
+		fmt.Println("Error checking window:", err)
+		os.Exit(1)
+	}
+
+	if altab.StyleHidden(style) {
+		fmt.Println("hidden")
+	} else {
+		fmt.Println("shown")
+	}
+
+	if verbose {
+		fmt.Printf("WS_EX_TOOLWINDOW=%t WS_EX_APPWINDOW=%t\n",
+			style&altab.WS_EX_TOOLWINDOW != 0, style&altab.WS_EX_APPWINDOW != 0)
+	}
+}
@@ -0,0 +1,55 @@
+//go:build linux
+// +build linux
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/BurntSushi/xgb"
+	"gwctl/internal/altab"
+	"gwctl/internal/target"
+	"gwctl/internal/x11"
+)
+
+func main() {
+	var windowTitle string
+	var targetFlags target.Flags
+	var verbose bool
+	flag.StringVar(&windowTitle, "title", "", "Window title to check")
+	targetFlags.Register()
+	flag.BoolVar(&verbose, "verbose", false, "Also print the window states the result is based on")
+	flag.Parse()
+
+	conn, err := xgb.NewConn()
+	if err != nil {
+		fmt.Println("Error opening display:", err)
+		os.Exit(1)
+	}
+	defer conn.Close()
+
+	window, err := target.Window(conn, windowTitle, targetFlags, x11.FindWindow)
+	if err != nil {
+		fmt.Println("Error finding window:", err)
+		os.Exit(1)
+	}
+
+	states, err := x11.WindowStates(conn, window)
+	if err != nil {
+		fmt.Println("Error checking window:", err)
+		os.Exit(1)
+	}
+
+	if altab.StatesHidden(states) {
+		fmt.Println("hidden")
+	} else {
+		fmt.Println("shown")
+	}
+
+	if verbose {
+		fmt.Printf("_NET_WM_STATE_SKIP_TASKBAR=%t _NET_WM_STATE_SKIP_PAGER=%t\n",
+			states["_NET_WM_STATE_SKIP_TASKBAR"], states["_NET_WM_STATE_SKIP_PAGER"])
+	}
+}
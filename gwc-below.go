@@ -0,0 +1,108 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"syscall"
+	"unsafe"
+
+	"gwctl/internal/target"
+)
+
+var (
+	modUser32        = syscall.NewLazyDLL("user32.dll")
+	procFindWindow   = modUser32.NewProc("FindWindowW")
+	procSetWindowPos = modUser32.NewProc("SetWindowPos")
+	procGetWindow    = modUser32.NewProc("GetWindow")
+	HWND_TOP         = 0
+	HWND_BOTTOM      = 1
+	GW_HWNDNEXT      = 2
+	SWP_NOSIZE       = 0x0001
+	SWP_NOMOVE       = 0x0002
+	SWP_NOACTIVATE   = 0x0010
+)
+
+func findWindow(windowName *uint16) (syscall.Handle, error) {
+	ret, _, err := procFindWindow.Call(uintptr(unsafe.Pointer(nil)), uintptr(unsafe.Pointer(windowName)))
+	if ret == 0 {
+		return 0, err
+	}
+	return syscall.Handle(ret), nil
+}
+
+// isAtBottom reports whether no top-level window is below hwnd. Windows
+// has no keep-below state, so this is the closest thing to query.
+func isAtBottom(hwnd syscall.Handle) bool {
+	next, _, _ := procGetWindow.Call(uintptr(hwnd), uintptr(GW_HWNDNEXT))
+	return next == 0
+}
+
+func setWindowZOrder(hwnd syscall.Handle, insertAfter int) error {
+	ret, _, err := procSetWindowPos.Call(
+		uintptr(hwnd),
+		uintptr(insertAfter),
+		0,
+		0,
+		0,
+		0,
+		uintptr(SWP_NOMOVE|SWP_NOSIZE|SWP_NOACTIVATE),
+	)
+	if ret == 0 {
+		return fmt.Errorf("failed to set window position: %v", err)
+	}
+	return nil
+}
+
+func main() {
+	var windowTitle string
+	var targetFlags target.Flags
+	flag.StringVar(&windowTitle, "title", "", "Window title to find and move below other windows")
+	targetFlags.Register()
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "Usage: gwc-below -title TITLE [on|off|toggle]")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	action := "on"
+	if flag.NArg() > 0 {
+		action = flag.Arg(0)
+	}
+
+	hwnd, err := target.Window(windowTitle, targetFlags, findWindow)
+	if err != nil {
+		fmt.Println("Error finding window:", err)
+		os.Exit(1)
+	}
+
+	var below bool
+	switch action {
+	case "on":
+		below = true
+	case "off":
+		below = false
+	case "toggle":
+		below = !isAtBottom(hwnd)
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	insertAfter := HWND_TOP
+	if below {
+		insertAfter = HWND_BOTTOM
+	}
+
+	err = setWindowZOrder(hwnd, insertAfter)
+	if err != nil {
+		fmt.Println("Error changing window z-order:", err)
+		os.Exit(1)
+	}
+
+	if below {
+		fmt.Println("Window moved below other windows.")
+	} else {
+		fmt.Println("Window moved above other windows.")
+	}
+}
@@ -0,0 +1,64 @@
+//go:build linux
+// +build linux
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/BurntSushi/xgb"
+	"gwctl/internal/target"
+	"gwctl/internal/x11"
+)
+
+func main() {
+	var windowTitle string
+	var targetFlags target.Flags
+	var timeout time.Duration
+	flag.StringVar(&windowTitle, "title", "", "Window title to find and keep below other windows")
+	targetFlags.Register()
+	flag.DurationVar(&timeout, "timeout", time.Second, "How long to wait for the window manager to apply the change")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "Usage: gwc-below -title TITLE [on|off|toggle]")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	action := "on"
+	if flag.NArg() > 0 {
+		action = flag.Arg(0)
+	}
+
+	stateAction, ok := x11.ParseStateAction(action)
+	if !ok {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	conn, err := xgb.NewConn()
+	if err != nil {
+		fmt.Println("Error opening display:", err)
+		os.Exit(1)
+	}
+	defer conn.Close()
+
+	window, err := target.Window(conn, windowTitle, targetFlags, x11.FindWindow)
+	if err != nil {
+		fmt.Println("Error finding window:", err)
+		os.Exit(1)
+	}
+
+	enabled, err := x11.ChangeState(conn, window, stateAction, "_NET_WM_STATE_BELOW", timeout)
+	if err != nil {
+		fmt.Println("Error changing window z-order:", err)
+		os.Exit(1)
+	}
+	if enabled {
+		fmt.Println("Window is kept below other windows.")
+	} else {
+		fmt.Println("Window is no longer kept below other windows.")
+	}
+}
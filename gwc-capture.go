@@ -0,0 +1,239 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"unsafe"
+
+	"gwctl/internal/target"
+)
+
+var (
+	modUser32              = syscall.NewLazyDLL("user32.dll")
+	procFindWindow         = modUser32.NewProc("FindWindowW")
+	procGetWindowRect      = modUser32.NewProc("GetWindowRect")
+	procGetWindowDC        = modUser32.NewProc("GetWindowDC")
+	procReleaseDC          = modUser32.NewProc("ReleaseDC")
+	procPrintWindow        = modUser32.NewProc("PrintWindow")
+	modGdi32               = syscall.NewLazyDLL("gdi32.dll")
+	procCreateCompatibleDC = modGdi32.NewProc("CreateCompatibleDC")
+	procCreateDIBSection   = modGdi32.NewProc("CreateDIBSection")
+	procSelectObject       = modGdi32.NewProc("SelectObject")
+	procBitBlt             = modGdi32.NewProc("BitBlt")
+	procDeleteObject       = modGdi32.NewProc("DeleteObject")
+	procDeleteDC           = modGdi32.NewProc("DeleteDC")
+	procGdiFlush           = modGdi32.NewProc("GdiFlush")
+	PW_RENDERFULLCONTENT   = 0x00000002
+	SRCCOPY                = 0x00CC0020
+	DIB_RGB_COLORS         = 0
+)
+
+type bitmapInfoHeader struct {
+	size          uint32
+	width         int32
+	height        int32
+	planes        uint16
+	bitCount      uint16
+	compression   uint32
+	sizeImage     uint32
+	xPelsPerMeter int32
+	yPelsPerMeter int32
+	clrUsed       uint32
+	clrImportant  uint32
+}
+
+type cropRect struct {
+	x, y, width, height int
+}
+
+func findWindow(windowName *uint16) (syscall.Handle, error) {
+	ret, _, err := procFindWindow.Call(uintptr(unsafe.Pointer(nil)), uintptr(unsafe.Pointer(windowName)))
+	if ret == 0 {
+		return 0, err
+	}
+	return syscall.Handle(ret), nil
+}
+
+func parseCrop(s string) (cropRect, error) {
+	var r cropRect
+	if s == "" {
+		return r, nil
+	}
+	_, err := fmt.Sscanf(s, "%d,%d,%d,%d", &r.x, &r.y, &r.width, &r.height)
+	if err != nil || r.x < 0 || r.y < 0 || r.width <= 0 || r.height <= 0 {
+		return r, fmt.Errorf("invalid crop '%s', expected X,Y,WIDTH,HEIGHT", s)
+	}
+	return r, nil
+}
+
+// clampCrop limits the crop region to the window, or returns the whole
+// window if no crop was given.
+func clampCrop(r cropRect, width, height int) (cropRect, error) {
+	if r.width == 0 {
+		return cropRect{0, 0, width, height}, nil
+	}
+	if r.x >= width || r.y >= height {
+		return r, fmt.Errorf("crop region is outside the %dx%d window", width, height)
+	}
+	r.width = min(r.width, width-r.x)
+	r.height = min(r.height, height-r.y)
+	return r, nil
+}
+
+// captureWindow renders the whole window, including its frame, into a
+// bitmap. PrintWindow also captures obscured windows; windows that do not
+// draw through it are copied from the screen instead.
+func captureWindow(hwnd syscall.Handle) (*image.RGBA, error) {
+	var rect struct {
+		left, top, right, bottom int32
+	}
+	ret, _, err := procGetWindowRect.Call(uintptr(hwnd), uintptr(unsafe.Pointer(&rect)))
+	if ret == 0 {
+		return nil, fmt.Errorf("failed to get window rect: %v", err)
+	}
+	width, height := int(rect.right-rect.left), int(rect.bottom-rect.top)
+	if width <= 0 || height <= 0 {
+		return nil, fmt.Errorf("window has no area")
+	}
+
+	windowDC, _, _ := procGetWindowDC.Call(uintptr(hwnd))
+	if windowDC == 0 {
+		return nil, fmt.Errorf("failed to get window DC")
+	}
+	defer procReleaseDC.Call(uintptr(hwnd), windowDC)
+
+	memDC, _, _ := procCreateCompatibleDC.Call(windowDC)
+	if memDC == 0 {
+		return nil, fmt.Errorf("failed to create memory DC")
+	}
+	defer procDeleteDC.Call(memDC)
+
+	// A negative height makes the bitmap top-down, like image.RGBA.
+	header := bitmapInfoHeader{
+		width:    int32(width),
+		height:   -int32(height),
+		planes:   1,
+		bitCount: 32,
+	}
+	header.size = uint32(unsafe.Sizeof(header))
+
+	var bits unsafe.Pointer
+	bitmap, _, _ := procCreateDIBSection.Call(windowDC, uintptr(unsafe.Pointer(&header)),
+		uintptr(DIB_RGB_COLORS), uintptr(unsafe.Pointer(&bits)), 0, 0)
+	if bitmap == 0 {
+		return nil, fmt.Errorf("failed to create bitmap")
+	}
+	defer procDeleteObject.Call(bitmap)
+
+	old, _, _ := procSelectObject.Call(memDC, bitmap)
+	defer procSelectObject.Call(memDC, old)
+
+	ret, _, _ = procPrintWindow.Call(uintptr(hwnd), memDC, uintptr(PW_RENDERFULLCONTENT))
+	if ret == 0 {
+		ret, _, err = procBitBlt.Call(memDC, 0, 0, uintptr(width), uintptr(height), windowDC, 0, 0, uintptr(SRCCOPY))
+		if ret == 0 {
+			return nil, fmt.Errorf("failed to copy window contents: %v", err)
+		}
+	}
+	procGdiFlush.Call()
+
+	// The bitmap is BGRX; alpha is not meaningful for window contents.
+	data := unsafe.Slice((*byte)(bits), width*height*4)
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for i := 0; i < len(data); i += 4 {
+		img.Pix[i+0] = data[i+2]
+		img.Pix[i+1] = data[i+1]
+		img.Pix[i+2] = data[i+0]
+		img.Pix[i+3] = 0xff
+	}
+	return img, nil
+}
+
+func writeImage(img image.Image, path, format string, quality int) error {
+	if format == "" {
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+	}
+
+	// Check the format first, so a bad one leaves no empty file behind.
+	jpegFormat := false
+	switch format {
+	case "jpg", "jpeg":
+		jpegFormat = true
+	case "png", "":
+	default:
+		return fmt.Errorf("unknown image format '%s', expected png or jpeg", format)
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if jpegFormat {
+		err = jpeg.Encode(f, img, &jpeg.Options{Quality: quality})
+	} else {
+		err = png.Encode(f, img)
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+func main() {
+	var windowTitle, output, crop, format string
+	var targetFlags target.Flags
+	var quality int
+	flag.StringVar(&windowTitle, "title", "", "Window title to find and capture")
+	targetFlags.Register()
+	flag.StringVar(&output, "o", "", "Image file to write")
+	flag.StringVar(&crop, "crop", "", "Region of the window to capture, as X,Y,WIDTH,HEIGHT")
+	flag.StringVar(&format, "format", "", "Image format: png or jpeg (default from the file extension)")
+	flag.IntVar(&quality, "quality", 90, "JPEG quality, 1 to 100")
+	flag.Parse()
+
+	if output == "" {
+		fmt.Println("Please provide an output file using the -o flag.")
+		os.Exit(1)
+	}
+	region, err := parseCrop(crop)
+	if err != nil {
+		fmt.Println("Error:", err)
+		os.Exit(1)
+	}
+
+	hwnd, err := target.Window(windowTitle, targetFlags, findWindow)
+	if err != nil {
+		fmt.Println("Error finding window:", err)
+		os.Exit(1)
+	}
+
+	img, err := captureWindow(hwnd)
+	if err != nil {
+		fmt.Println("Error capturing window:", err)
+		os.Exit(1)
+	}
+
+	bounds := img.Bounds()
+	region, err = clampCrop(region, bounds.Dx(), bounds.Dy())
+	if err != nil {
+		fmt.Println("Error:", err)
+		os.Exit(1)
+	}
+	cropped := img.SubImage(image.Rect(region.x, region.y, region.x+region.width, region.y+region.height))
+
+	err = writeImage(cropped, output, format, quality)
+	if err != nil {
+		fmt.Println("Error writing image:", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Captured %dx%d to %s.\n", region.width, region.height, output)
+}
@@ -0,0 +1,236 @@
+//go:build linux
+// +build linux
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/xgb"
+	"github.com/BurntSushi/xgb/composite"
+	"github.com/BurntSushi/xgb/xproto"
+	"gwctl/internal/target"
+	"gwctl/internal/x11"
+)
+
+type cropRect struct {
+	x, y, width, height int
+}
+
+func parseCrop(s string) (cropRect, error) {
+	var r cropRect
+	if s == "" {
+		return r, nil
+	}
+	_, err := fmt.Sscanf(s, "%d,%d,%d,%d", &r.x, &r.y, &r.width, &r.height)
+	if err != nil || r.x < 0 || r.y < 0 || r.width <= 0 || r.height <= 0 {
+		return r, fmt.Errorf("invalid crop '%s', expected X,Y,WIDTH,HEIGHT", s)
+	}
+	return r, nil
+}
+
+// clampCrop limits the crop region to the window, or returns the whole
+// window if no crop was given.
+func clampCrop(r cropRect, width, height int) (cropRect, error) {
+	if r.width == 0 {
+		return cropRect{0, 0, width, height}, nil
+	}
+	if r.x >= width || r.y >= height {
+		return r, fmt.Errorf("crop region is outside the %dx%d window", width, height)
+	}
+	r.width = min(r.width, width-r.x)
+	r.height = min(r.height, height-r.y)
+	return r, nil
+}
+
+// redirectWindow has the window rendered to an offscreen pixmap with the
+// Composite extension, so obscured parts are captured too. It returns the
+// drawable to read from and a function to undo the redirection, or the
+// window itself if Composite is not available.
+func redirectWindow(conn *xgb.Conn, window xproto.Window, delay time.Duration) (xproto.Drawable, func()) {
+	noop := func() {}
+	if composite.Init(conn) != nil {
+		return xproto.Drawable(window), noop
+	}
+	if _, err := composite.QueryVersion(conn, 0, 2).Reply(); err != nil {
+		return xproto.Drawable(window), noop
+	}
+	if composite.RedirectWindowChecked(conn, window, composite.RedirectAutomatic).Check() != nil {
+		return xproto.Drawable(window), noop
+	}
+
+	// A freshly redirected window is only filled in once the application
+	// has redrawn it.
+	time.Sleep(delay)
+
+	pixmap, err := xproto.NewPixmapId(conn)
+	if err == nil {
+		err = composite.NameWindowPixmapChecked(conn, window, pixmap).Check()
+	}
+	if err != nil {
+		composite.UnredirectWindow(conn, window, composite.RedirectAutomatic)
+		return xproto.Drawable(window), noop
+	}
+
+	return xproto.Drawable(pixmap), func() {
+		xproto.FreePixmap(conn, pixmap)
+		composite.UnredirectWindow(conn, window, composite.RedirectAutomatic)
+	}
+}
+
+// captureImage reads a region of the drawable. Only the common 24 and 32
+// bit TrueColor visuals, stored as 32 bits per pixel, are supported.
+func captureImage(conn *xgb.Conn, drawable xproto.Drawable, depth byte, r cropRect) (*image.RGBA, error) {
+	setup := xproto.Setup(conn)
+
+	var bpp, pad int
+	for _, format := range setup.PixmapFormats {
+		if format.Depth == depth {
+			bpp, pad = int(format.BitsPerPixel), int(format.ScanlinePad)
+		}
+	}
+	if bpp != 32 || depth != 24 && depth != 32 {
+		return nil, fmt.Errorf("unsupported window depth %d", depth)
+	}
+
+	reply, err := xproto.GetImage(conn, xproto.ImageFormatZPixmap, drawable,
+		int16(r.x), int16(r.y), uint16(r.width), uint16(r.height), 0xffffffff).Reply()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get window image: %v", err)
+	}
+
+	stride := (r.width*bpp/8 + pad/8 - 1) / (pad / 8) * (pad / 8)
+	if len(reply.Data) < stride*r.height {
+		return nil, fmt.Errorf("short window image: %d bytes", len(reply.Data))
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, r.width, r.height))
+	for y := 0; y < r.height; y++ {
+		for x := 0; x < r.width; x++ {
+			p := reply.Data[y*stride+x*4:]
+			var c color.RGBA
+			if setup.ImageByteOrder == xproto.ImageOrderLSBFirst {
+				c = color.RGBA{p[2], p[1], p[0], p[3]}
+			} else {
+				c = color.RGBA{p[1], p[2], p[3], p[0]}
+			}
+			if depth == 24 {
+				c.A = 0xff
+			}
+			img.SetRGBA(x, y, c)
+		}
+	}
+	return img, nil
+}
+
+func writeImage(img image.Image, path, format string, quality int) error {
+	if format == "" {
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+	}
+
+	// Check the format first, so a bad one leaves no empty file behind.
+	jpegFormat := false
+	switch format {
+	case "jpg", "jpeg":
+		jpegFormat = true
+	case "png", "":
+	default:
+		return fmt.Errorf("unknown image format '%s', expected png or jpeg", format)
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if jpegFormat {
+		err = jpeg.Encode(f, img, &jpeg.Options{Quality: quality})
+	} else {
+		err = png.Encode(f, img)
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+func main() {
+	var windowTitle, output, crop, format string
+	var targetFlags target.Flags
+	var quality int
+	var noComposite bool
+	var delay time.Duration
+	flag.StringVar(&windowTitle, "title", "", "Window title to find and capture")
+	targetFlags.Register()
+	flag.StringVar(&output, "o", "", "Image file to write")
+	flag.StringVar(&crop, "crop", "", "Region of the window to capture, as X,Y,WIDTH,HEIGHT")
+	flag.StringVar(&format, "format", "", "Image format: png or jpeg (default from the file extension)")
+	flag.IntVar(&quality, "quality", 90, "JPEG quality, 1 to 100")
+	flag.BoolVar(&noComposite, "no-composite", false, "Read the window directly instead of through the Composite extension")
+	flag.DurationVar(&delay, "delay", 100*time.Millisecond, "How long to let the window redraw after redirecting it")
+	flag.Parse()
+
+	if output == "" {
+		fmt.Println("Please provide an output file using the -o flag.")
+		os.Exit(1)
+	}
+	region, err := parseCrop(crop)
+	if err != nil {
+		fmt.Println("Error:", err)
+		os.Exit(1)
+	}
+
+	conn, err := xgb.NewConn()
+	if err != nil {
+		fmt.Println("Error opening display:", err)
+		os.Exit(1)
+	}
+	defer conn.Close()
+
+	window, err := target.Window(conn, windowTitle, targetFlags, x11.FindWindow)
+	if err != nil {
+		fmt.Println("Error finding window:", err)
+		os.Exit(1)
+	}
+
+	geom, err := xproto.GetGeometry(conn, xproto.Drawable(window)).Reply()
+	if err != nil {
+		fmt.Println("Error getting window geometry:", err)
+		os.Exit(1)
+	}
+	region, err = clampCrop(region, int(geom.Width), int(geom.Height))
+	if err != nil {
+		fmt.Println("Error:", err)
+		os.Exit(1)
+	}
+
+	drawable := xproto.Drawable(window)
+	if !noComposite {
+		var release func()
+		drawable, release = redirectWindow(conn, window, delay)
+		defer release()
+	}
+
+	img, err := captureImage(conn, drawable, geom.Depth, region)
+	if err != nil {
+		fmt.Println("Error capturing window:", err)
+		os.Exit(1)
+	}
+
+	err = writeImage(img, output, format, quality)
+	if err != nil {
+		fmt.Println("Error writing image:", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Captured %dx%d to %s.\n", region.width, region.height, output)
+}
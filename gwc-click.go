@@ -0,0 +1,173 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"syscall"
+	"time"
+	"unsafe"
+
+	"gwctl/internal/target"
+)
+
+var (
+	modUser32         = syscall.NewLazyDLL("user32.dll")
+	procFindWindow    = modUser32.NewProc("FindWindowW")
+	procGetWindowRect = modUser32.NewProc("GetWindowRect")
+	procSetCursorPos  = modUser32.NewProc("SetCursorPos")
+	procSendInput     = modUser32.NewProc("SendInput")
+	INPUT_MOUSE       = 0
+)
+
+type rect struct {
+	left, top, right, bottom int32
+}
+
+func findWindow(windowName *uint16) (syscall.Handle, error) {
+	ret, _, err := procFindWindow.Call(uintptr(unsafe.Pointer(nil)), uintptr(unsafe.Pointer(windowName)))
+	if ret == 0 {
+		return 0, err
+	}
+	return syscall.Handle(ret), nil
+}
+
+func getWindowRect(hwnd syscall.Handle) (rect, error) {
+	var r rect
+	ret, _, err := procGetWindowRect.Call(uintptr(hwnd), uintptr(unsafe.Pointer(&r)))
+	if ret == 0 {
+		return r, fmt.Errorf("failed to get window rect: %v", err)
+	}
+	return r, nil
+}
+
+// screenPoint turns a position relative to the window's top-left corner
+// into screen coordinates, or takes the window's center if center is set.
+func screenPoint(hwnd syscall.Handle, x, y int, center bool) (int32, int32, error) {
+	r, err := getWindowRect(hwnd)
+	if err != nil {
+		return 0, 0, err
+	}
+	if center {
+		return (r.left + r.right) / 2, (r.top + r.bottom) / 2, nil
+	}
+	return r.left + int32(x), r.top + int32(y), nil
+}
+
+func parsePoint(s string) (int, int, error) {
+	var x, y int
+	if _, err := fmt.Sscanf(s, "%d,%d", &x, &y); err != nil {
+		return 0, 0, fmt.Errorf("invalid position '%s', expected X,Y", s)
+	}
+	return x, y, nil
+}
+
+func warpPointer(x, y int32) error {
+	ret, _, err := procSetCursorPos.Call(uintptr(x), uintptr(y))
+	if ret == 0 {
+		return fmt.Errorf("failed to move pointer: %v", err)
+	}
+	return nil
+}
+
+// mouseInput is the INPUT structure with its MOUSEINPUT member, the
+// largest member of the union.
+type mouseInput struct {
+	typ uint32
+	mi  struct {
+		dx        int32
+		dy        int32
+		mouseData uint32
+		flags     uint32
+		time      uint32
+		extraInfo uintptr
+	}
+}
+
+// Button down and up flags for MOUSEINPUT.
+var buttons = map[string][2]uint32{
+	"left":   {0x0002, 0x0004},
+	"right":  {0x0008, 0x0010},
+	"middle": {0x0020, 0x0040},
+}
+
+func click(button string, count int) error {
+	flags := buttons[button]
+
+	var inputs []mouseInput
+	for i := 0; i < count; i++ {
+		for _, f := range flags {
+			input := mouseInput{typ: uint32(INPUT_MOUSE)}
+			input.mi.flags = f
+			inputs = append(inputs, input)
+		}
+	}
+
+	ret, _, err := procSendInput.Call(uintptr(len(inputs)),
+		uintptr(unsafe.Pointer(&inputs[0])), unsafe.Sizeof(inputs[0]))
+	if int(ret) != len(inputs) {
+		return fmt.Errorf("SendInput was blocked: %v", err)
+	}
+	return nil
+}
+
+func main() {
+	var windowTitle, at, button string
+	var targetFlags target.Flags
+	var center, double bool
+	var delay time.Duration
+	flag.StringVar(&windowTitle, "title", "", "Window title to find and click into")
+	targetFlags.Register()
+	flag.StringVar(&at, "at", "", "Position relative to the window's top-left corner, as X,Y")
+	flag.BoolVar(&center, "center", false, "Click the center of the window")
+	flag.StringVar(&button, "button", "left", "Mouse button: left, right or middle")
+	flag.BoolVar(&double, "double", false, "Double-click")
+	flag.DurationVar(&delay, "delay", 20*time.Millisecond, "Delay between moving the pointer and clicking")
+	flag.Parse()
+
+	if (at == "") == !center {
+		fmt.Println("Please provide either -at X,Y or -center.")
+		os.Exit(1)
+	}
+	if _, ok := buttons[button]; !ok {
+		fmt.Println("Unknown button:", button)
+		os.Exit(1)
+	}
+	var x, y int
+	if at != "" {
+		var err error
+		if x, y, err = parsePoint(at); err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+	}
+
+	hwnd, err := target.Window(windowTitle, targetFlags, findWindow)
+	if err != nil {
+		fmt.Println("Error finding window:", err)
+		os.Exit(1)
+	}
+
+	screenX, screenY, err := screenPoint(hwnd, x, y, center)
+	if err != nil {
+		fmt.Println("Error:", err)
+		os.Exit(1)
+	}
+
+	err = warpPointer(screenX, screenY)
+	if err != nil {
+		fmt.Println("Error:", err)
+		os.Exit(1)
+	}
+	time.Sleep(delay)
+
+	count := 1
+	if double {
+		count = 2
+	}
+	err = click(button, count)
+	if err != nil {
+		fmt.Println("Error clicking:", err)
+		os.Exit(1)
+	}
+}
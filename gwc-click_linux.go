@@ -0,0 +1,140 @@
+//go:build linux
+// +build linux
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/BurntSushi/xgb"
+	"github.com/BurntSushi/xgb/xproto"
+	"github.com/BurntSushi/xgb/xtest"
+	"gwctl/internal/target"
+	"gwctl/internal/x11"
+)
+
+func parsePoint(s string) (int, int, error) {
+	var x, y int
+	if _, err := fmt.Sscanf(s, "%d,%d", &x, &y); err != nil {
+		return 0, 0, fmt.Errorf("invalid position '%s', expected X,Y", s)
+	}
+	return x, y, nil
+}
+
+// rootPoint turns a position relative to the window's top-left corner
+// into root window coordinates, or takes the window's center if center is
+// set.
+func rootPoint(conn *xgb.Conn, window xproto.Window, x, y int, center bool) (int16, int16, error) {
+	if center {
+		geom, err := xproto.GetGeometry(conn, xproto.Drawable(window)).Reply()
+		if err != nil {
+			return 0, 0, fmt.Errorf("failed to get window geometry: %v", err)
+		}
+		x, y = int(geom.Width)/2, int(geom.Height)/2
+	}
+
+	root := xproto.Setup(conn).DefaultScreen(conn).Root
+	pos, err := xproto.TranslateCoordinates(conn, window, root, int16(x), int16(y)).Reply()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to get window position: %v", err)
+	}
+	return pos.DstX, pos.DstY, nil
+}
+
+func warpPointer(conn *xgb.Conn, x, y int16) error {
+	root := xproto.Setup(conn).DefaultScreen(conn).Root
+	err := xproto.WarpPointerChecked(conn, 0, root, 0, 0, 0, 0, x, y).Check()
+	if err != nil {
+		return fmt.Errorf("failed to move pointer: %v", err)
+	}
+	return nil
+}
+
+var buttons = map[string]byte{
+	"left":   1,
+	"middle": 2,
+	"right":  3,
+}
+
+func click(conn *xgb.Conn, button byte, count int) error {
+	if err := xtest.Init(conn); err != nil {
+		return fmt.Errorf("XTEST extension not available: %v", err)
+	}
+
+	root := xproto.Setup(conn).DefaultScreen(conn).Root
+	for i := 0; i < count; i++ {
+		xtest.FakeInput(conn, xproto.ButtonPress, button, 0, root, 0, 0, 0)
+		xtest.FakeInput(conn, xproto.ButtonRelease, button, 0, root, 0, 0, 0)
+	}
+	_, err := xproto.GetInputFocus(conn).Reply()
+	return err
+}
+
+func main() {
+	var windowTitle, at, button string
+	var targetFlags target.Flags
+	var center, double bool
+	var delay time.Duration
+	flag.StringVar(&windowTitle, "title", "", "Window title to find and click into")
+	targetFlags.Register()
+	flag.StringVar(&at, "at", "", "Position relative to the window's top-left corner, as X,Y")
+	flag.BoolVar(&center, "center", false, "Click the center of the window")
+	flag.StringVar(&button, "button", "left", "Mouse button: left, right or middle")
+	flag.BoolVar(&double, "double", false, "Double-click")
+	flag.DurationVar(&delay, "delay", 20*time.Millisecond, "Delay between moving the pointer and clicking")
+	flag.Parse()
+
+	if (at == "") == !center {
+		fmt.Println("Please provide either -at X,Y or -center.")
+		os.Exit(1)
+	}
+	buttonNumber, ok := buttons[button]
+	if !ok {
+		fmt.Println("Unknown button:", button)
+		os.Exit(1)
+	}
+	var x, y int
+	if at != "" {
+		var err error
+		if x, y, err = parsePoint(at); err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+	}
+
+	conn, err := xgb.NewConn()
+	if err != nil {
+		fmt.Println("Error opening display:", err)
+		os.Exit(1)
+	}
+	defer conn.Close()
+
+	window, err := target.Window(conn, windowTitle, targetFlags, x11.FindWindow)
+	if err != nil {
+		fmt.Println("Error finding window:", err)
+		os.Exit(1)
+	}
+
+	rootX, rootY, err := rootPoint(conn, window, x, y, center)
+	if err == nil {
+		err = warpPointer(conn, rootX, rootY)
+	}
+	if err != nil {
+		fmt.Println("Error:", err)
+		os.Exit(1)
+	}
+	time.Sleep(delay)
+
+	count := 1
+	if double {
+		count = 2
+	}
+	err = click(conn, buttonNumber, count)
+	if err != nil {
+		fmt.Println("Error clicking:", err)
+		os.Exit(1)
+	}
+}
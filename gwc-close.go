@@ -0,0 +1,86 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"syscall"
+	"time"
+	"unsafe"
+
+	"gwctl/internal/target"
+)
+
+var (
+	modUser32       = syscall.NewLazyDLL("user32.dll")
+	procFindWindow  = modUser32.NewProc("FindWindowW")
+	procPostMessage = modUser32.NewProc("PostMessageW")
+	procIsWindow    = modUser32.NewProc("IsWindow")
+	WM_CLOSE        = 0x0010
+)
+
+func findWindow(windowName *uint16) (syscall.Handle, error) {
+	ret, _, err := procFindWindow.Call(uintptr(unsafe.Pointer(nil)), uintptr(unsafe.Pointer(windowName)))
+	if ret == 0 {
+		return 0, err
+	}
+	return syscall.Handle(ret), nil
+}
+
+// closeWindow asks the window to close, as its close button would. The
+// application may still refuse, e.g. to ask about unsaved changes.
+func closeWindow(hwnd syscall.Handle) error {
+	ret, _, err := procPostMessage.Call(uintptr(hwnd), uintptr(WM_CLOSE), 0, 0)
+	if ret == 0 {
+		return fmt.Errorf("failed to post WM_CLOSE: %v", err)
+	}
+	return nil
+}
+
+// waitForGone waits until the window no longer exists, or until timeout,
+// and reports whether it is gone.
+func waitForGone(hwnd syscall.Handle, timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for {
+		if ret, _, _ := procIsWindow.Call(uintptr(hwnd)); ret == 0 {
+			return true
+		}
+		if !time.Now().Before(deadline) {
+			return false
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+func main() {
+	var windowTitle string
+	var targetFlags target.Flags
+	var wait bool
+	var timeout time.Duration
+	flag.StringVar(&windowTitle, "title", "", "Window title to find and close")
+	targetFlags.Register()
+	flag.BoolVar(&wait, "wait", false, "Wait until the window is gone")
+	flag.DurationVar(&timeout, "timeout", 5*time.Second, "How long to wait with -wait")
+	flag.Parse()
+
+	hwnd, err := target.Window(windowTitle, targetFlags, findWindow)
+	if err != nil {
+		fmt.Println("Error finding window:", err)
+		os.Exit(1)
+	}
+
+	err = closeWindow(hwnd)
+	if err != nil {
+		fmt.Println("Error closing window:", err)
+		os.Exit(1)
+	}
+
+	if !wait {
+		return
+	}
+	if !waitForGone(hwnd, timeout) {
+		fmt.Println("Window is still open.")
+		os.Exit(1)
+	}
+	fmt.Println("Window closed.")
+}
@@ -0,0 +1,148 @@
+//go:build linux
+// +build linux
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/BurntSushi/xgb"
+	"github.com/BurntSushi/xgb/xproto"
+	"gwctl/internal/target"
+	"gwctl/internal/x11"
+)
+
+func supportsDeleteWindow(conn *xgb.Conn, window xproto.Window) bool {
+	protocols, err := x11.InternAtom(conn, "WM_PROTOCOLS")
+	if err != nil {
+		return false
+	}
+	deleteWindow, err := x11.InternAtom(conn, "WM_DELETE_WINDOW")
+	if err != nil {
+		return false
+	}
+	reply, err := xproto.GetProperty(conn, false, window,
+		protocols, xproto.AtomAtom, 0, (1<<32)-1).Reply()
+	if err != nil {
+		return false
+	}
+	for i := 0; i+4 <= len(reply.Value); i += 4 {
+		if xproto.Atom(xgb.Get32(reply.Value[i:])) == deleteWindow {
+			return true
+		}
+	}
+	return false
+}
+
+// closeWindow asks the window to close, as its close button would: through
+// the window manager if it supports _NET_CLOSE_WINDOW, otherwise with the
+// ICCCM WM_DELETE_WINDOW protocol. The application may still refuse.
+func closeWindow(conn *xgb.Conn, window xproto.Window) error {
+	if x11.WMSupports(conn, "_NET_CLOSE_WINDOW") {
+		return x11.SendClientMessage(conn, window, "_NET_CLOSE_WINDOW", uint32(xproto.TimeCurrentTime), x11.SourcePager)
+	}
+
+	if !supportsDeleteWindow(conn, window) {
+		return fmt.Errorf("window does not support WM_DELETE_WINDOW, use gwc-kill instead")
+	}
+
+	protocols, err := x11.InternAtom(conn, "WM_PROTOCOLS")
+	if err != nil {
+		return err
+	}
+	deleteWindow, err := x11.InternAtom(conn, "WM_DELETE_WINDOW")
+	if err != nil {
+		return err
+	}
+	event := xproto.ClientMessageEvent{
+		Format: 32,
+		Window: window,
+		Type:   protocols,
+		Data: xproto.ClientMessageDataUnionData32New([]uint32{
+			uint32(deleteWindow), uint32(xproto.TimeCurrentTime), 0, 0, 0}),
+	}
+	return xproto.SendEventChecked(conn, false, window, xproto.EventMaskNoEvent, string(event.Bytes())).Check()
+}
+
+// waitForGone waits until the window is destroyed or withdrawn, or until
+// timeout, and reports whether it is gone. Being unmapped does not count:
+// iconified windows and windows on other desktops are unmapped too. A
+// window that is only withdrawn loses its WM_STATE, which is what some
+// applications do when asked to close.
+func waitForGone(conn *xgb.Conn, window xproto.Window, timeout time.Duration) bool {
+	wmState, err := x11.InternAtom(conn, "WM_STATE")
+	if err != nil {
+		return false
+	}
+	// A frame or other window without WM_STATE can only be gone by being
+	// destroyed.
+	_, managed := windowState(conn, window, wmState)
+
+	deadline := time.Now().Add(timeout)
+	for {
+		exists, hasState := windowState(conn, window, wmState)
+		if !exists || managed && !hasState {
+			return true
+		}
+		if !time.Now().Before(deadline) {
+			return false
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// windowState reports whether the window exists and whether it has
+// WM_STATE. Errors other than BadWindow leave the window counted as
+// existing.
+func windowState(conn *xgb.Conn, window xproto.Window, wmState xproto.Atom) (exists, hasState bool) {
+	reply, err := xproto.GetProperty(conn, false, window,
+		wmState, xproto.GetPropertyTypeAny, 0, 0).Reply()
+	if err != nil {
+		_, bad := err.(xproto.WindowError)
+		return !bad, true
+	}
+	return true, reply.Format != 0
+}
+
+func main() {
+	var windowTitle string
+	var targetFlags target.Flags
+	var wait bool
+	var timeout time.Duration
+	flag.StringVar(&windowTitle, "title", "", "Window title to find and close")
+	targetFlags.Register()
+	flag.BoolVar(&wait, "wait", false, "Wait until the window is gone")
+	flag.DurationVar(&timeout, "timeout", 5*time.Second, "How long to wait with -wait")
+	flag.Parse()
+
+	conn, err := xgb.NewConn()
+	if err != nil {
+		fmt.Println("Error opening display:", err)
+		os.Exit(1)
+	}
+	defer conn.Close()
+
+	window, err := target.Window(conn, windowTitle, targetFlags, x11.FindWindow)
+	if err != nil {
+		fmt.Println("Error finding window:", err)
+		os.Exit(1)
+	}
+
+	err = closeWindow(conn, window)
+	if err != nil {
+		fmt.Println("Error closing window:", err)
+		os.Exit(1)
+	}
+
+	if !wait {
+		return
+	}
+	if !waitForGone(conn, window, timeout) {
+		fmt.Println("Window is still open.")
+		os.Exit(1)
+	}
+	fmt.Println("Window closed.")
+}
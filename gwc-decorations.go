@@ -0,0 +1,130 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"syscall"
+	"unsafe"
+
+	"gwctl/internal/target"
+)
+
+var (
+	modUser32         = syscall.NewLazyDLL("user32.dll")
+	procFindWindow    = modUser32.NewProc("FindWindowW")
+	procSetWindowPos  = modUser32.NewProc("SetWindowPos")
+	procGetWindowLong = modUser32.NewProc("GetWindowLongW")
+	procSetWindowLong = modUser32.NewProc("SetWindowLongW")
+	procSetProp       = modUser32.NewProc("SetPropW")
+	procGetProp       = modUser32.NewProc("GetPropW")
+	procRemoveProp    = modUser32.NewProc("RemovePropW")
+	GWL_STYLE         = -16
+	WS_CAPTION        = 0x00C00000
+	WS_THICKFRAME     = 0x00040000
+	SWP_NOSIZE        = 0x0001
+	SWP_NOMOVE        = 0x0002
+	SWP_NOZORDER      = 0x0004
+	SWP_NOACTIVATE    = 0x0010
+	SWP_FRAMECHANGED  = 0x0020
+)
+
+// propDecorations holds the style bits removed by turning decorations off.
+const propDecorations = "gwctl.decorations"
+
+func findWindow(windowName *uint16) (syscall.Handle, error) {
+	ret, _, err := procFindWindow.Call(uintptr(unsafe.Pointer(nil)), uintptr(unsafe.Pointer(windowName)))
+	if ret == 0 {
+		return 0, err
+	}
+	return syscall.Handle(ret), nil
+}
+
+func isDecorated(hwnd syscall.Handle) bool {
+	style, _, _ := procGetWindowLong.Call(uintptr(hwnd), uintptr(GWL_STYLE))
+	return style&uintptr(WS_CAPTION) == uintptr(WS_CAPTION)
+}
+
+func setDecorated(hwnd syscall.Handle, decorated bool) error {
+	namePtr := uintptr(unsafe.Pointer(syscall.StringToUTF16Ptr(propDecorations)))
+	style, _, _ := procGetWindowLong.Call(uintptr(hwnd), uintptr(GWL_STYLE))
+
+	if decorated {
+		// Put back what was removed, or a plain caption and sizing border.
+		bits, _, _ := procGetProp.Call(uintptr(hwnd), namePtr)
+		if bits == 0 {
+			bits = uintptr(WS_CAPTION | WS_THICKFRAME)
+		}
+		procRemoveProp.Call(uintptr(hwnd), namePtr)
+		style |= bits
+	} else {
+		if removed := style & uintptr(WS_CAPTION|WS_THICKFRAME); removed != 0 {
+			procSetProp.Call(uintptr(hwnd), namePtr, removed)
+		}
+		style &^= uintptr(WS_CAPTION | WS_THICKFRAME)
+	}
+	procSetWindowLong.Call(uintptr(hwnd), uintptr(GWL_STYLE), style)
+
+	// The frame is only redrawn once the window is told it changed.
+	ret, _, err := procSetWindowPos.Call(
+		uintptr(hwnd),
+		0,
+		0,
+		0,
+		0,
+		0,
+		uintptr(SWP_NOMOVE|SWP_NOSIZE|SWP_NOZORDER|SWP_NOACTIVATE|SWP_FRAMECHANGED),
+	)
+	if ret == 0 {
+		return fmt.Errorf("failed to update window frame: %v", err)
+	}
+	return nil
+}
+
+func main() {
+	var windowTitle string
+	var targetFlags target.Flags
+	flag.StringVar(&windowTitle, "title", "", "Window title to find and change the decorations of")
+	targetFlags.Register()
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "Usage: gwc-decorations -title TITLE [on|off|toggle]")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	action := "off"
+	if flag.NArg() > 0 {
+		action = flag.Arg(0)
+	}
+
+	hwnd, err := target.Window(windowTitle, targetFlags, findWindow)
+	if err != nil {
+		fmt.Println("Error finding window:", err)
+		os.Exit(1)
+	}
+
+	var decorated bool
+	switch action {
+	case "on":
+		decorated = true
+	case "off":
+		decorated = false
+	case "toggle":
+		decorated = !isDecorated(hwnd)
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	err = setDecorated(hwnd, decorated)
+	if err != nil {
+		fmt.Println("Error changing window decorations:", err)
+		os.Exit(1)
+	}
+
+	if isDecorated(hwnd) {
+		fmt.Println("Window is decorated.")
+	} else {
+		fmt.Println("Window is borderless.")
+	}
+}
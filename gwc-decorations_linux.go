@@ -0,0 +1,140 @@
+//go:build linux
+// +build linux
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/BurntSushi/xgb"
+	"github.com/BurntSushi/xgb/xproto"
+	"gwctl/internal/target"
+	"gwctl/internal/x11"
+)
+
+// _MOTIF_WM_HINTS fields, as defined by the Motif window manager and
+// honored by most others.
+const (
+	mwmHintsDecorations = 1 << 1
+	mwmDecorAll         = 1 << 0
+	mwmHintsLength      = 5
+)
+
+// getMotifHints returns the window's _MOTIF_WM_HINTS, or nil if it has none.
+func getMotifHints(conn *xgb.Conn, window xproto.Window) ([]uint32, error) {
+	atom, err := x11.InternAtom(conn, "_MOTIF_WM_HINTS")
+	if err != nil {
+		return nil, err
+	}
+	reply, err := xproto.GetProperty(conn, false, window,
+		atom, atom, 0, mwmHintsLength).Reply()
+	if err != nil {
+		return nil, err
+	}
+	if len(reply.Value) < mwmHintsLength*4 {
+		return nil, nil
+	}
+
+	hints := make([]uint32, mwmHintsLength)
+	for i := range hints {
+		hints[i] = xgb.Get32(reply.Value[i*4:])
+	}
+	return hints, nil
+}
+
+func isDecorated(hints []uint32) bool {
+	return hints == nil || hints[0]&mwmHintsDecorations == 0 || hints[2] != 0
+}
+
+// setDecorated changes only the decorations field, so hints the
+// application set for the window functions are kept.
+func setDecorated(conn *xgb.Conn, window xproto.Window, hints []uint32, decorated bool) error {
+	atom, err := x11.InternAtom(conn, "_MOTIF_WM_HINTS")
+	if err != nil {
+		return err
+	}
+
+	if hints == nil {
+		hints = make([]uint32, mwmHintsLength)
+	}
+	hints[0] |= mwmHintsDecorations
+	hints[2] = 0
+	if decorated {
+		hints[2] = mwmDecorAll
+	}
+
+	buf := make([]byte, mwmHintsLength*4)
+	for i, value := range hints {
+		xgb.Put32(buf[i*4:], value)
+	}
+	return xproto.ChangePropertyChecked(conn, xproto.PropModeReplace, window,
+		atom, atom, 32, mwmHintsLength, buf).Check()
+}
+
+func main() {
+	var windowTitle string
+	var targetFlags target.Flags
+	flag.StringVar(&windowTitle, "title", "", "Window title to find and change the decorations of")
+	targetFlags.Register()
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "Usage: gwc-decorations -title TITLE [on|off|toggle]")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	action := "off"
+	if flag.NArg() > 0 {
+		action = flag.Arg(0)
+	}
+
+	conn, err := xgb.NewConn()
+	if err != nil {
+		fmt.Println("Error opening display:", err)
+		os.Exit(1)
+	}
+	defer conn.Close()
+
+	window, err := target.Window(conn, windowTitle, targetFlags, x11.FindWindow)
+	if err != nil {
+		fmt.Println("Error finding window:", err)
+		os.Exit(1)
+	}
+
+	hints, err := getMotifHints(conn, window)
+	if err != nil {
+		fmt.Println("Error reading window decorations:", err)
+		os.Exit(1)
+	}
+
+	var decorated bool
+	switch action {
+	case "on":
+		decorated = true
+	case "off":
+		decorated = false
+	case "toggle":
+		decorated = !isDecorated(hints)
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	err = setDecorated(conn, window, hints, decorated)
+	if err != nil {
+		fmt.Println("Error changing window decorations:", err)
+		os.Exit(1)
+	}
+
+	hints, err = getMotifHints(conn, window)
+	if err != nil {
+		fmt.Println("Error reading window decorations:", err)
+		os.Exit(1)
+	}
+	if isDecorated(hints) {
+		fmt.Println("Window is decorated.")
+	} else {
+		fmt.Println("Window is borderless.")
+	}
+}
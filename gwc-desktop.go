@@ -0,0 +1,148 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	modKernel32               = syscall.NewLazyDLL("kernel32.dll")
+	procProcessIdToSessionId  = modKernel32.NewProc("ProcessIdToSessionId")
+	procGetCurrentProcessId   = modKernel32.NewProc("GetCurrentProcessId")
+	virtualDesktopsKey        = `Software\Microsoft\Windows\CurrentVersion\Explorer\VirtualDesktops`
+	sessionVirtualDesktopsKey = `Software\Microsoft\Windows\CurrentVersion\Explorer\SessionInfo\%d\VirtualDesktops`
+)
+
+// readRegistryValue reads a value below HKEY_CURRENT_USER.
+func readRegistryValue(path, name string) ([]byte, error) {
+	var key syscall.Handle
+	err := syscall.RegOpenKeyEx(syscall.HKEY_CURRENT_USER, syscall.StringToUTF16Ptr(path), 0, syscall.KEY_READ, &key)
+	if err != nil {
+		return nil, err
+	}
+	defer syscall.RegCloseKey(key)
+
+	var size uint32
+	namePtr := syscall.StringToUTF16Ptr(name)
+	err = syscall.RegQueryValueEx(key, namePtr, nil, nil, nil, &size)
+	if err != nil {
+		return nil, err
+	}
+	if size == 0 {
+		return nil, nil
+	}
+	buf := make([]byte, size)
+	err = syscall.RegQueryValueEx(key, namePtr, nil, nil, &buf[0], &size)
+	if err != nil {
+		return nil, err
+	}
+	return buf[:size], nil
+}
+
+// formatGUID formats a GUID the way the registry keys below
+// VirtualDesktops\Desktops are named.
+func formatGUID(b []byte) string {
+	return fmt.Sprintf("{%08X-%04X-%04X-%X-%X}",
+		uint32(b[0])|uint32(b[1])<<8|uint32(b[2])<<16|uint32(b[3])<<24,
+		uint16(b[4])|uint16(b[5])<<8,
+		uint16(b[6])|uint16(b[7])<<8,
+		b[8:10], b[10:16])
+}
+
+// listDesktops returns the IDs of the virtual desktops in order. Windows
+// has no public API for this, so it is read from where Explorer keeps it.
+func listDesktops() ([]string, error) {
+	ids, err := readRegistryValue(virtualDesktopsKey, "VirtualDesktopIDs")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read virtual desktops: %v", err)
+	}
+
+	var desktops []string
+	for i := 0; i+16 <= len(ids); i += 16 {
+		desktops = append(desktops, formatGUID(ids[i:i+16]))
+	}
+	return desktops, nil
+}
+
+// currentDesktop returns the ID of the current virtual desktop. Windows 11
+// keeps it per user, Windows 10 per session.
+func currentDesktop() (string, error) {
+	id, err := readRegistryValue(virtualDesktopsKey, "CurrentVirtualDesktop")
+	if err != nil || len(id) < 16 {
+		pid, _, _ := procGetCurrentProcessId.Call()
+		var session uint32
+		procProcessIdToSessionId.Call(pid, uintptr(unsafe.Pointer(&session)))
+		id, err = readRegistryValue(fmt.Sprintf(sessionVirtualDesktopsKey, session), "CurrentVirtualDesktop")
+	}
+	if err != nil || len(id) < 16 {
+		return "", fmt.Errorf("failed to read current virtual desktop: %v", err)
+	}
+	return formatGUID(id), nil
+}
+
+func desktopName(id string, index int) string {
+	name, err := readRegistryValue(virtualDesktopsKey+`\Desktops\`+id, "Name")
+	if err != nil || len(name) < 2 {
+		return fmt.Sprintf("Desktop %d", index+1)
+	}
+	chars := make([]uint16, len(name)/2)
+	for i := range chars {
+		chars[i] = uint16(name[2*i]) | uint16(name[2*i+1])<<8
+	}
+	return syscall.UTF16ToString(chars)
+}
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "Usage: gwc-desktop [list|current|switch N]")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	action := "list"
+	if flag.NArg() > 0 {
+		action = flag.Arg(0)
+	}
+
+	desktops, err := listDesktops()
+	if err != nil {
+		fmt.Println("Error listing desktops:", err)
+		os.Exit(1)
+	}
+	current, err := currentDesktop()
+	if err != nil {
+		fmt.Println("Error finding current desktop:", err)
+		os.Exit(1)
+	}
+
+	switch action {
+	case "list":
+		for i, id := range desktops {
+			marker := " "
+			if id == current {
+				marker = "*"
+			}
+			fmt.Printf("%s %d %s\n", marker, i, desktopName(id, i))
+		}
+	case "current":
+		for i, id := range desktops {
+			if id == current {
+				fmt.Println(i)
+				return
+			}
+		}
+		fmt.Println("Current desktop not found.")
+		os.Exit(1)
+	case "switch":
+		// IVirtualDesktopManager can only query and move windows; switching
+		// desktops needs undocumented interfaces that change between builds.
+		fmt.Println("Switching desktops is not supported on Windows.")
+		os.Exit(1)
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+}
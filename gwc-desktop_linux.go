@@ -0,0 +1,119 @@
+//go:build linux
+// +build linux
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/xgb"
+	"github.com/BurntSushi/xgb/xproto"
+	"gwctl/internal/x11"
+)
+
+// getDesktopNames returns _NET_DESKTOP_NAMES, which may list fewer names
+// than there are desktops.
+func getDesktopNames(conn *xgb.Conn, root xproto.Window) []string {
+	names, err := x11.InternAtom(conn, "_NET_DESKTOP_NAMES")
+	if err != nil {
+		return nil
+	}
+	reply, err := xproto.GetProperty(conn, false, root,
+		names, xproto.GetPropertyTypeAny, 0, (1<<32)-1).Reply()
+	if err != nil || reply == nil || reply.ValueLen == 0 {
+		return nil
+	}
+	return strings.Split(strings.TrimRight(string(reply.Value), "\x00"), "\x00")
+}
+
+// waitForDesktop polls _NET_CURRENT_DESKTOP until it is want or the timeout
+// passes, and returns the last value read.
+func waitForDesktop(conn *xgb.Conn, root xproto.Window, want uint32, timeout time.Duration) uint32 {
+	deadline := time.Now().Add(timeout)
+	for {
+		current, _ := x11.Cardinal(conn, root, "_NET_CURRENT_DESKTOP")
+		if current == want || time.Now().After(deadline) {
+			return current
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func main() {
+	var timeout time.Duration
+	flag.DurationVar(&timeout, "timeout", time.Second, "How long to wait for the window manager to switch desktops")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "Usage: gwc-desktop [list|current|switch N]")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	action := "list"
+	if flag.NArg() > 0 {
+		action = flag.Arg(0)
+	}
+
+	conn, err := xgb.NewConn()
+	if err != nil {
+		fmt.Println("Error opening display:", err)
+		os.Exit(1)
+	}
+	defer conn.Close()
+
+	root := xproto.Setup(conn).DefaultScreen(conn).Root
+
+	count, ok := x11.Cardinal(conn, root, "_NET_NUMBER_OF_DESKTOPS")
+	if !ok {
+		fmt.Println("The window manager does not support virtual desktops.")
+		os.Exit(1)
+	}
+	current, _ := x11.Cardinal(conn, root, "_NET_CURRENT_DESKTOP")
+
+	switch action {
+	case "list":
+		names := getDesktopNames(conn, root)
+		for i := uint32(0); i < count; i++ {
+			marker := " "
+			if i == current {
+				marker = "*"
+			}
+			name := ""
+			if int(i) < len(names) {
+				name = names[i]
+			}
+			fmt.Printf("%s %d %s\n", marker, i, name)
+		}
+	case "current":
+		fmt.Println(current)
+	case "switch":
+		if flag.NArg() != 2 {
+			flag.Usage()
+			os.Exit(2)
+		}
+		desktop, err := strconv.ParseUint(flag.Arg(1), 10, 32)
+		if err != nil || uint32(desktop) >= count {
+			fmt.Printf("Invalid desktop '%s', expected 0 to %d.\n", flag.Arg(1), count-1)
+			os.Exit(1)
+		}
+
+		err = x11.SendClientMessage(conn, root, "_NET_CURRENT_DESKTOP", uint32(desktop), uint32(xproto.TimeCurrentTime))
+		if err != nil {
+			fmt.Println("Error switching desktop:", err)
+			os.Exit(1)
+		}
+
+		if waitForDesktop(conn, root, uint32(desktop), timeout) != uint32(desktop) {
+			fmt.Println("The window manager did not switch desktops.")
+			os.Exit(1)
+		}
+		fmt.Printf("Switched to desktop %d.\n", desktop)
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+}
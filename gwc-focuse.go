@@ -3,14 +3,30 @@ package main
 import (
 	"flag"
 	"fmt"
+	"os"
 	"syscall"
+	"time"
 	"unsafe"
+
+	"gwctl/internal/target"
 )
 
 var (
-	modUser32               = syscall.NewLazyDLL("user32.dll")
-	procFindWindowEx        = modUser32.NewProc("FindWindowExW")
-	procSetForegroundWindow = modUser32.NewProc("SetForegroundWindow")
+	modUser32                    = syscall.NewLazyDLL("user32.dll")
+	procFindWindowEx             = modUser32.NewProc("FindWindowExW")
+	procSetForegroundWindow      = modUser32.NewProc("SetForegroundWindow")
+	procGetForegroundWindow      = modUser32.NewProc("GetForegroundWindow")
+	procGetWindowThreadProcessId = modUser32.NewProc("GetWindowThreadProcessId")
+	procAttachThreadInput        = modUser32.NewProc("AttachThreadInput")
+	procBringWindowToTop         = modUser32.NewProc("BringWindowToTop")
+	procShowWindow               = modUser32.NewProc("ShowWindow")
+	procIsIconic                 = modUser32.NewProc("IsIconic")
+	procKeybdEvent               = modUser32.NewProc("keybd_event")
+	modKernel32                  = syscall.NewLazyDLL("kernel32.dll")
+	procGetCurrentThreadId       = modKernel32.NewProc("GetCurrentThreadId")
+	SW_RESTORE                   = 9
+	VK_MENU                      = 0x12
+	KEYEVENTF_KEYUP              = 0x0002
 )
 
 func findWindowEx(parentHwnd syscall.Handle, childAfter syscall.Handle, className, windowName *uint16) (syscall.Handle, error) {
@@ -26,24 +42,83 @@ func findWindowEx(parentHwnd syscall.Handle, childAfter syscall.Handle, classNam
 	return syscall.Handle(ret), nil
 }
 
-func setForegroundWindow(hwnd syscall.Handle) {
-	procSetForegroundWindow.Call(uintptr(hwnd))
+func findWindow(windowName *uint16) (syscall.Handle, error) {
+	return findWindowEx(0, 0, nil, windowName)
+}
+
+func getForegroundWindow() syscall.Handle {
+	ret, _, _ := procGetForegroundWindow.Call()
+	return syscall.Handle(ret)
+}
+
+func setForegroundWindow(hwnd syscall.Handle) bool {
+	ret, _, _ := procSetForegroundWindow.Call(uintptr(hwnd))
+	return ret != 0
+}
+
+// forceForegroundWindow works around the foreground lock, which refuses
+// SetForegroundWindow to processes that did not receive the last input:
+// it joins the input queue of the current foreground thread and sends a
+// harmless Alt press, which counts as that input.
+func forceForegroundWindow(hwnd syscall.Handle) bool {
+	foreground := getForegroundWindow()
+	foregroundThread, _, _ := procGetWindowThreadProcessId.Call(uintptr(foreground), 0)
+	currentThread, _, _ := procGetCurrentThreadId.Call()
+
+	if foregroundThread != 0 && foregroundThread != currentThread {
+		procAttachThreadInput.Call(currentThread, foregroundThread, 1)
+		defer procAttachThreadInput.Call(currentThread, foregroundThread, 0)
+	}
+
+	procKeybdEvent.Call(uintptr(VK_MENU), 0, 0, 0)
+	procKeybdEvent.Call(uintptr(VK_MENU), 0, uintptr(KEYEVENTF_KEYUP), 0)
+
+	procBringWindowToTop.Call(uintptr(hwnd))
+	return setForegroundWindow(hwnd)
+}
+
+// waitForForeground waits until the window is the foreground window, or
+// until timeout, and reports whether it got there.
+func waitForForeground(hwnd syscall.Handle, timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for {
+		if getForegroundWindow() == hwnd {
+			return true
+		}
+		if !time.Now().Before(deadline) {
+			return false
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func activateWindow(hwnd syscall.Handle, timeout time.Duration) bool {
+	if ret, _, _ := procIsIconic.Call(uintptr(hwnd)); ret != 0 {
+		procShowWindow.Call(uintptr(hwnd), uintptr(SW_RESTORE))
+	}
+
+	if setForegroundWindow(hwnd) && waitForForeground(hwnd, timeout) {
+		return true
+	}
+	return forceForegroundWindow(hwnd) && waitForForeground(hwnd, timeout)
 }
 
 func main() {
+	var targetFlags target.Flags
 	windowTitle := flag.String("title", "", "Window title to focus")
+	targetFlags.Register()
+	timeout := flag.Duration("timeout", time.Second, "How long to wait for the window to become the foreground window")
 
 	flag.Parse()
 
-	parentHwnd := syscall.Handle(0) // 0 for the desktop window
-	childAfter := syscall.Handle(0) // 0 to start the search from the beginning of the Z order
-	windowName := syscall.StringToUTF16Ptr(*windowTitle)
-
-	hwnd, err := findWindowEx(parentHwnd, childAfter, nil, windowName)
+	hwnd, err := target.Window(*windowTitle, targetFlags, findWindow)
 	if err != nil {
 		fmt.Println("Error finding window:", err)
-		return
+		os.Exit(1)
 	}
 
-	setForegroundWindow(hwnd)
+	if !activateWindow(hwnd, *timeout) {
+		fmt.Println("Window could not be brought to the foreground.")
+		os.Exit(1)
+	}
 }
@@ -0,0 +1,42 @@
+//go:build linux
+// +build linux
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/BurntSushi/xgb"
+	"gwctl/internal/target"
+	"gwctl/internal/x11"
+)
+
+func main() {
+	var targetFlags target.Flags
+	windowTitle := flag.String("title", "", "Window title to focus")
+	targetFlags.Register()
+	timeout := flag.Duration("timeout", time.Second, "How long to wait for the window to get the focus")
+
+	flag.Parse()
+
+	conn, err := xgb.NewConn()
+	if err != nil {
+		fmt.Println("Error opening display:", err)
+		os.Exit(1)
+	}
+	defer conn.Close()
+
+	window, err := target.Window(conn, *windowTitle, targetFlags, x11.FindWindow)
+	if err != nil {
+		fmt.Println("Error finding window:", err)
+		os.Exit(1)
+	}
+
+	if !x11.ActivateWindow(conn, window, *timeout) {
+		fmt.Println("Window could not be focused.")
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"syscall"
+	"unsafe"
+
+	"gwctl/internal/target"
+)
+
+var (
+	modUser32                = syscall.NewLazyDLL("user32.dll")
+	procFindWindow           = modUser32.NewProc("FindWindowW")
+	procGetWindowRect        = modUser32.NewProc("GetWindowRect")
+	procSetWindowPos         = modUser32.NewProc("SetWindowPos")
+	procGetWindowLong        = modUser32.NewProc("GetWindowLongW")
+	procSetWindowLong        = modUser32.NewProc("SetWindowLongW")
+	procMonitorFromWindow    = modUser32.NewProc("MonitorFromWindow")
+	procGetMonitorInfo       = modUser32.NewProc("GetMonitorInfoW")
+	procSetProp              = modUser32.NewProc("SetPropW")
+	procGetProp              = modUser32.NewProc("GetPropW")
+	procRemoveProp           = modUser32.NewProc("RemovePropW")
+	GWL_STYLE                = -16
+	WS_CAPTION               = 0x00C00000
+	WS_THICKFRAME            = 0x00040000
+	HWND_TOP                 = 0
+	SWP_NOZORDER             = 0x0004
+	SWP_NOACTIVATE           = 0x0010
+	SWP_FRAMECHANGED         = 0x0020
+	MONITOR_DEFAULTTONEAREST = 0x00000002
+)
+
+// Window properties holding the style and position from before going
+// fullscreen. propStyle also marks the window as fullscreen.
+const (
+	propStyle  = "gwctl.fullscreen.style"
+	propX      = "gwctl.fullscreen.x"
+	propY      = "gwctl.fullscreen.y"
+	propWidth  = "gwctl.fullscreen.width"
+	propHeight = "gwctl.fullscreen.height"
+)
+
+type rect struct {
+	left, top, right, bottom int32
+}
+
+type monitorInfo struct {
+	size    uint32
+	monitor rect
+	work    rect
+	flags   uint32
+}
+
+func findWindow(windowName *uint16) (syscall.Handle, error) {
+	ret, _, err := procFindWindow.Call(uintptr(unsafe.Pointer(nil)), uintptr(unsafe.Pointer(windowName)))
+	if ret == 0 {
+		return 0, err
+	}
+	return syscall.Handle(ret), nil
+}
+
+func setProp(hwnd syscall.Handle, name string, value uintptr) {
+	procSetProp.Call(uintptr(hwnd), uintptr(unsafe.Pointer(syscall.StringToUTF16Ptr(name))), value)
+}
+
+func getProp(hwnd syscall.Handle, name string) uintptr {
+	value, _, _ := procGetProp.Call(uintptr(hwnd), uintptr(unsafe.Pointer(syscall.StringToUTF16Ptr(name))))
+	return value
+}
+
+func removeProp(hwnd syscall.Handle, name string) {
+	procRemoveProp.Call(uintptr(hwnd), uintptr(unsafe.Pointer(syscall.StringToUTF16Ptr(name))))
+}
+
+func isFullscreen(hwnd syscall.Handle) bool {
+	return getProp(hwnd, propStyle) != 0
+}
+
+func setWindowRect(hwnd syscall.Handle, r rect) error {
+	ret, _, err := procSetWindowPos.Call(
+		uintptr(hwnd),
+		uintptr(HWND_TOP),
+		uintptr(r.left),
+		uintptr(r.top),
+		uintptr(r.right-r.left),
+		uintptr(r.bottom-r.top),
+		uintptr(SWP_NOZORDER|SWP_NOACTIVATE|SWP_FRAMECHANGED),
+	)
+	if ret == 0 {
+		return fmt.Errorf("failed to set window position: %v", err)
+	}
+	return nil
+}
+
+// enterFullscreen makes the window borderless and covers the monitor it is
+// mostly on, remembering its style and position for leaveFullscreen.
+func enterFullscreen(hwnd syscall.Handle) error {
+	if isFullscreen(hwnd) {
+		return nil
+	}
+
+	var r rect
+	ret, _, err := procGetWindowRect.Call(uintptr(hwnd), uintptr(unsafe.Pointer(&r)))
+	if ret == 0 {
+		return fmt.Errorf("failed to get window rect: %v", err)
+	}
+
+	monitor, _, _ := procMonitorFromWindow.Call(uintptr(hwnd), uintptr(MONITOR_DEFAULTTONEAREST))
+	info := monitorInfo{size: uint32(unsafe.Sizeof(monitorInfo{}))}
+	ret, _, err = procGetMonitorInfo.Call(monitor, uintptr(unsafe.Pointer(&info)))
+	if ret == 0 {
+		return fmt.Errorf("failed to get monitor info: %v", err)
+	}
+
+	style, _, _ := procGetWindowLong.Call(uintptr(hwnd), uintptr(GWL_STYLE))
+	setProp(hwnd, propStyle, style)
+	setProp(hwnd, propX, uintptr(r.left))
+	setProp(hwnd, propY, uintptr(r.top))
+	setProp(hwnd, propWidth, uintptr(r.right-r.left))
+	setProp(hwnd, propHeight, uintptr(r.bottom-r.top))
+
+	procSetWindowLong.Call(uintptr(hwnd), uintptr(GWL_STYLE), style&^uintptr(WS_CAPTION|WS_THICKFRAME))
+	return setWindowRect(hwnd, info.monitor)
+}
+
+func leaveFullscreen(hwnd syscall.Handle) error {
+	if !isFullscreen(hwnd) {
+		return nil
+	}
+
+	x := int32(getProp(hwnd, propX))
+	y := int32(getProp(hwnd, propY))
+	r := rect{x, y, x + int32(getProp(hwnd, propWidth)), y + int32(getProp(hwnd, propHeight))}
+
+	procSetWindowLong.Call(uintptr(hwnd), uintptr(GWL_STYLE), getProp(hwnd, propStyle))
+	for _, name := range []string{propStyle, propX, propY, propWidth, propHeight} {
+		removeProp(hwnd, name)
+	}
+	return setWindowRect(hwnd, r)
+}
+
+func main() {
+	var windowTitle string
+	var targetFlags target.Flags
+	flag.StringVar(&windowTitle, "title", "", "Window title to find and make fullscreen")
+	targetFlags.Register()
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "Usage: gwc-fullscreen -title TITLE [on|off|toggle]")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	action := "on"
+	if flag.NArg() > 0 {
+		action = flag.Arg(0)
+	}
+
+	hwnd, err := target.Window(windowTitle, targetFlags, findWindow)
+	if err != nil {
+		fmt.Println("Error finding window:", err)
+		os.Exit(1)
+	}
+
+	var fullscreen bool
+	switch action {
+	case "on":
+		fullscreen = true
+	case "off":
+		fullscreen = false
+	case "toggle":
+		fullscreen = !isFullscreen(hwnd)
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if fullscreen {
+		err = enterFullscreen(hwnd)
+	} else {
+		err = leaveFullscreen(hwnd)
+	}
+	if err != nil {
+		fmt.Println("Error changing fullscreen state:", err)
+		os.Exit(1)
+	}
+
+	if isFullscreen(hwnd) {
+		fmt.Println("Window is fullscreen.")
+	} else {
+		fmt.Println("Window is no longer fullscreen.")
+	}
+}
@@ -3,18 +3,17 @@ package main
 import (
 	"flag"
 	"fmt"
+	"os"
 	"syscall"
 	"unsafe"
+
+	"gwctl/internal/altab"
+	"gwctl/internal/target"
 )
 
 var (
-	modUser32        = syscall.NewLazyDLL("user32.dll")
-	procFindWindow   = modUser32.NewProc("FindWindowW")
-	procSetWindowLong = modUser32.NewProc("SetWindowLongW")
-	procGetWindowLong = modUser32.NewProc("GetWindowLongW")
-	GWL_EXSTYLE      = -20
-	WS_EX_APPWINDOW  = 0x00040000
-	WS_EX_TOOLWINDOW = 0x00000080
+	modUser32      = syscall.NewLazyDLL("user32.dll")
+	procFindWindow = modUser32.NewProc("FindWindowW")
 )
 
 func findWindow(windowName *uint16) (syscall.Handle, error) {
@@ -25,47 +24,34 @@ func findWindow(windowName *uint16) (syscall.Handle, error) {
 	return syscall.Handle(ret), nil
 }
 
-func hideWindowAltTab(hwnd syscall.Handle) error {
-	style, _, _ := procGetWindowLong.Call(uintptr(hwnd), uintptr(GWL_EXSTYLE))
-	if style == 0 {
-		return fmt.Errorf("failed to get window style")
-	}
-	_, _, err := procSetWindowLong.Call(uintptr(hwnd), uintptr(GWL_EXSTYLE), (style|uintptr(WS_EX_TOOLWINDOW)) &^ uintptr(WS_EX_APPWINDOW))
-	if err != syscall.Errno(0) {
-		return fmt.Errorf("failed to set window style: %v", err)
-	}
-	return nil
-}
-
 func main() {
 	var windowTitle string
+	var targetFlags target.Flags
 	flag.StringVar(&windowTitle, "title", "", "Window title to find and hide from Alt+Tab")
+	targetFlags.Register()
 	flag.Parse()
 
-	if windowTitle == "" {
-		fmt.Println("Please provide a window title using the -title flag.")
-		return
-	}
-
-	windowName := syscall.StringToUTF16Ptr(windowTitle)
-
-	hwnd, err := findWindow(windowName)
+	hwnd, err := target.Window(windowTitle, targetFlags, findWindow)
 	if err != nil {
 		fmt.Println("Error finding window:", err)
-		return
+		os.Exit(1)
 	}
 
 	if hwnd == 0 {
 		fmt.Println("Window not found.")
-		return
+		os.Exit(1)
 	}
 
-	err = hideWindowAltTab(hwnd)
+	hidden, err := altab.SetStyleHidden(altab.Window(hwnd), true)
 	if err != nil {
 		fmt.Println("Error hiding window from Alt+Tab:", err)
-		return
+		os.Exit(1)
 	}
 
-	fmt.Println("Window hidden from Alt+Tab successfully.")
-}
+	if !hidden {
+		fmt.Println("Window style did not change, window is still shown in Alt+Tab.")
+		os.Exit(1)
+	}
 
+	fmt.Println("Window hidden from Alt+Tab.")
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"syscall"
+	"unsafe"
+
+	"gwctl/internal/target"
+)
+
+var (
+	modUser32                      = syscall.NewLazyDLL("user32.dll")
+	procFindWindow                 = modUser32.NewProc("FindWindowW")
+	procGetWindowText              = modUser32.NewProc("GetWindowTextW")
+	procGetClassName               = modUser32.NewProc("GetClassNameW")
+	procGetWindowRect              = modUser32.NewProc("GetWindowRect")
+	procGetWindowThreadProcessId   = modUser32.NewProc("GetWindowThreadProcessId")
+	procIsWindowVisible            = modUser32.NewProc("IsWindowVisible")
+	procIsIconic                   = modUser32.NewProc("IsIconic")
+	procIsZoomed                   = modUser32.NewProc("IsZoomed")
+	procGetWindowLong              = modUser32.NewProc("GetWindowLongW")
+	procGetLayeredWindowAttributes = modUser32.NewProc("GetLayeredWindowAttributes")
+	GWL_EXSTYLE                    = -20
+	WS_EX_TOPMOST                  = 0x00000008
+	WS_EX_LAYERED                  = 0x00080000
+	LWA_ALPHA                      = 0x00000002
+)
+
+func findWindow(windowName *uint16) (syscall.Handle, error) {
+	ret, _, err := procFindWindow.Call(uintptr(unsafe.Pointer(nil)), uintptr(unsafe.Pointer(windowName)))
+	if ret == 0 {
+		return 0, err
+	}
+	return syscall.Handle(ret), nil
+}
+
+func getWindowText(hwnd syscall.Handle) string {
+	buf := make([]uint16, 512)
+	procGetWindowText.Call(uintptr(hwnd), uintptr(unsafe.Pointer(&buf[0])), uintptr(len(buf)))
+	return syscall.UTF16ToString(buf)
+}
+
+func getClassName(hwnd syscall.Handle) string {
+	buf := make([]uint16, 256)
+	procGetClassName.Call(uintptr(hwnd), uintptr(unsafe.Pointer(&buf[0])), uintptr(len(buf)))
+	return syscall.UTF16ToString(buf)
+}
+
+func getOpacity(hwnd syscall.Handle, exStyle uintptr) float64 {
+	if exStyle&uintptr(WS_EX_LAYERED) == 0 {
+		return 1
+	}
+
+	var alpha byte
+	var flags uint32
+	ret, _, _ := procGetLayeredWindowAttributes.Call(uintptr(hwnd), 0,
+		uintptr(unsafe.Pointer(&alpha)), uintptr(unsafe.Pointer(&flags)))
+	if ret == 0 || flags&uint32(LWA_ALPHA) == 0 {
+		return 1
+	}
+	return float64(alpha) / 255
+}
+
+func isSet(proc *syscall.LazyProc, hwnd syscall.Handle) bool {
+	ret, _, _ := proc.Call(uintptr(hwnd))
+	return ret != 0
+}
+
+func main() {
+	var windowTitle string
+	var targetFlags target.Flags
+	flag.StringVar(&windowTitle, "title", "", "Window title to find and describe")
+	targetFlags.Register()
+	flag.Parse()
+
+	hwnd, err := target.Window(windowTitle, targetFlags, findWindow)
+	if err != nil {
+		fmt.Println("Error finding window:", err)
+		os.Exit(1)
+	}
+
+	var rect struct {
+		left, top, right, bottom int32
+	}
+	procGetWindowRect.Call(uintptr(hwnd), uintptr(unsafe.Pointer(&rect)))
+
+	var pid uint32
+	procGetWindowThreadProcessId.Call(uintptr(hwnd), uintptr(unsafe.Pointer(&pid)))
+
+	exStyle, _, _ := procGetWindowLong.Call(uintptr(hwnd), uintptr(GWL_EXSTYLE))
+
+	fmt.Printf("handle:    0x%x\n", uintptr(hwnd))
+	fmt.Printf("title:     %s\n", getWindowText(hwnd))
+	fmt.Printf("class:     %s\n", getClassName(hwnd))
+	fmt.Printf("pid:       %d\n", pid)
+	fmt.Printf("geometry:  %dx%d+%d+%d\n", rect.right-rect.left, rect.bottom-rect.top, rect.left, rect.top)
+	fmt.Printf("visible:   %t\n", isSet(procIsWindowVisible, hwnd))
+	fmt.Printf("minimized: %t\n", isSet(procIsIconic, hwnd))
+	fmt.Printf("maximized: %t\n", isSet(procIsZoomed, hwnd))
+	fmt.Printf("topmost:   %t\n", exStyle&uintptr(WS_EX_TOPMOST) != 0)
+	fmt.Printf("opacity:   %.2f\n", getOpacity(hwnd, exStyle))
+}
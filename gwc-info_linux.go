@@ -0,0 +1,105 @@
+//go:build linux
+// +build linux
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/xgb"
+	"github.com/BurntSushi/xgb/xproto"
+	"gwctl/internal/target"
+	"gwctl/internal/x11"
+)
+
+func getWindowStates(conn *xgb.Conn, window xproto.Window) []string {
+	atom, err := x11.InternAtom(conn, "_NET_WM_STATE")
+	if err != nil {
+		return nil
+	}
+	reply, err := xproto.GetProperty(conn, false, window,
+		atom, xproto.AtomAtom, 0, (1<<32)-1).Reply()
+	if err != nil || reply == nil {
+		return nil
+	}
+
+	var states []string
+	for i := 0; i+4 <= len(reply.Value); i += 4 {
+		nameReply, err := xproto.GetAtomName(conn, xproto.Atom(xgb.Get32(reply.Value[i:]))).Reply()
+		if err == nil {
+			states = append(states, strings.TrimPrefix(nameReply.Name, "_NET_WM_STATE_"))
+		}
+	}
+	sort.Strings(states)
+	return states
+}
+
+func main() {
+	var windowTitle string
+	var targetFlags target.Flags
+	flag.StringVar(&windowTitle, "title", "", "Window title to find and describe")
+	targetFlags.Register()
+	flag.Parse()
+
+	conn, err := xgb.NewConn()
+	if err != nil {
+		fmt.Println("Error opening display:", err)
+		os.Exit(1)
+	}
+	defer conn.Close()
+
+	window, err := target.Window(conn, windowTitle, targetFlags, x11.FindWindow)
+	if err != nil {
+		fmt.Println("Error finding window:", err)
+		os.Exit(1)
+	}
+
+	geom, err := xproto.GetGeometry(conn, xproto.Drawable(window)).Reply()
+	if err != nil {
+		fmt.Println("Error getting window geometry:", err)
+		os.Exit(1)
+	}
+	root := xproto.Setup(conn).DefaultScreen(conn).Root
+	pos, err := xproto.TranslateCoordinates(conn, window, root, 0, 0).Reply()
+	if err != nil {
+		fmt.Println("Error getting window position:", err)
+		os.Exit(1)
+	}
+
+	attrs, err := xproto.GetWindowAttributes(conn, window).Reply()
+	if err != nil {
+		fmt.Println("Error getting window attributes:", err)
+		os.Exit(1)
+	}
+
+	pid, _ := x11.Cardinal(conn, window, "_NET_WM_PID")
+
+	desktop := "-"
+	if d, ok := x11.Cardinal(conn, window, "_NET_WM_DESKTOP"); ok {
+		if d == 0xffffffff {
+			desktop = "all"
+		} else {
+			desktop = fmt.Sprint(d)
+		}
+	}
+
+	// Windows without _NET_WM_WINDOW_OPACITY are fully opaque.
+	opacity := 1.0
+	if value, ok := x11.Cardinal(conn, window, "_NET_WM_WINDOW_OPACITY"); ok {
+		opacity = float64(value) / 0xffffffff
+	}
+
+	fmt.Printf("id:       0x%x\n", uint32(window))
+	fmt.Printf("title:    %s\n", x11.WindowName(conn, window))
+	fmt.Printf("class:    %s\n", strings.Join(x11.WindowClass(conn, window), ", "))
+	fmt.Printf("pid:      %d\n", pid)
+	fmt.Printf("geometry: %dx%d+%d+%d\n", geom.Width, geom.Height, pos.DstX, pos.DstY)
+	fmt.Printf("mapped:   %t\n", attrs.MapState == xproto.MapStateViewable)
+	fmt.Printf("desktop:  %s\n", desktop)
+	fmt.Printf("states:   %s\n", strings.Join(getWindowStates(conn, window), " "))
+	fmt.Printf("opacity:  %.2f\n", opacity)
+}
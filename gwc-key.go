@@ -0,0 +1,243 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+	"unsafe"
+
+	"gwctl/internal/target"
+)
+
+var (
+	modUser32               = syscall.NewLazyDLL("user32.dll")
+	procFindWindow          = modUser32.NewProc("FindWindowW")
+	procSetForegroundWindow = modUser32.NewProc("SetForegroundWindow")
+	procGetForegroundWindow = modUser32.NewProc("GetForegroundWindow")
+	procSendInput           = modUser32.NewProc("SendInput")
+	procPostMessage         = modUser32.NewProc("PostMessageW")
+	procMapVirtualKey       = modUser32.NewProc("MapVirtualKeyW")
+	INPUT_KEYBOARD          = 1
+	KEYEVENTF_KEYUP         = 0x0002
+	WM_KEYDOWN              = 0x0100
+	WM_KEYUP                = 0x0101
+	MAPVK_VK_TO_VSC         = 0
+)
+
+// keyboardInput is the INPUT structure with its KEYBDINPUT member. The
+// padding makes it as large as the union's largest member, MOUSEINPUT.
+type keyboardInput struct {
+	typ uint32
+	ki  struct {
+		vk        uint16
+		scan      uint16
+		flags     uint32
+		time      uint32
+		extraInfo uintptr
+	}
+	_ [8]byte
+}
+
+func findWindow(windowName *uint16) (syscall.Handle, error) {
+	ret, _, err := procFindWindow.Call(uintptr(unsafe.Pointer(nil)), uintptr(unsafe.Pointer(windowName)))
+	if ret == 0 {
+		return 0, err
+	}
+	return syscall.Handle(ret), nil
+}
+
+// focusWindow brings the window to the foreground so that SendInput
+// reaches it, and waits up to timeout for that to happen.
+func focusWindow(hwnd syscall.Handle, timeout time.Duration) error {
+	procSetForegroundWindow.Call(uintptr(hwnd))
+
+	deadline := time.Now().Add(timeout)
+	for {
+		if fg, _, _ := procGetForegroundWindow.Call(); syscall.Handle(fg) == hwnd {
+			return nil
+		}
+		if !time.Now().Before(deadline) {
+			return fmt.Errorf("window did not come to the foreground, try gwc-focuse first")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func sendInputs(inputs []keyboardInput) error {
+	ret, _, err := procSendInput.Call(uintptr(len(inputs)),
+		uintptr(unsafe.Pointer(&inputs[0])), unsafe.Sizeof(inputs[0]))
+	if int(ret) != len(inputs) {
+		return fmt.Errorf("SendInput was blocked: %v", err)
+	}
+	return nil
+}
+
+func keyInput(vk, scan uint16, flags uint32) keyboardInput {
+	input := keyboardInput{typ: uint32(INPUT_KEYBOARD)}
+	input.ki.vk = vk
+	input.ki.scan = scan
+	input.ki.flags = flags
+	return input
+}
+
+// postKey posts a key press and release straight to the window's message
+// queue, which works without focus but cannot convey held modifiers.
+func postKey(hwnd syscall.Handle, vk uint16) error {
+	scan, _, _ := procMapVirtualKey.Call(uintptr(vk), uintptr(MAPVK_VK_TO_VSC))
+	lParam := 1 | scan<<16
+	ret, _, err := procPostMessage.Call(uintptr(hwnd), uintptr(WM_KEYDOWN), uintptr(vk), lParam)
+	if ret == 0 {
+		return fmt.Errorf("failed to post key: %v", err)
+	}
+	procPostMessage.Call(uintptr(hwnd), uintptr(WM_KEYUP), uintptr(vk), lParam|0xC0000000)
+	return nil
+}
+
+// Virtual-key codes of the keys that can be named in a key combination,
+// besides letters and digits, which are their own upper case ASCII code.
+var namedKeys = map[string]uint16{
+	"enter":     0x0D,
+	"return":    0x0D,
+	"tab":       0x09,
+	"escape":    0x1B,
+	"esc":       0x1B,
+	"space":     0x20,
+	"backspace": 0x08,
+	"delete":    0x2E,
+	"insert":    0x2D,
+	"home":      0x24,
+	"end":       0x23,
+	"pageup":    0x21,
+	"pagedown":  0x22,
+	"left":      0x25,
+	"up":        0x26,
+	"right":     0x27,
+	"down":      0x28,
+	"f1":        0x70,
+	"f2":        0x71,
+	"f3":        0x72,
+	"f4":        0x73,
+	"f5":        0x74,
+	"f6":        0x75,
+	"f7":        0x76,
+	"f8":        0x77,
+	"f9":        0x78,
+	"f10":       0x79,
+	"f11":       0x7A,
+	"f12":       0x7B,
+}
+
+var modifierKeys = map[string]uint16{
+	"ctrl":  0x11,
+	"shift": 0x10,
+	"alt":   0x12,
+	"super": 0x5B,
+}
+
+// parseKeyCombo parses the hotkey syntax of gwc-tray, e.g. "ctrl+shift+s",
+// extended with digits and named keys such as "enter" or "f5".
+func parseKeyCombo(combo string) ([]uint16, uint16, error) {
+	parts := strings.Split(strings.ToLower(combo), "+")
+	keyName := parts[len(parts)-1]
+
+	var mods []uint16
+	for _, name := range parts[:len(parts)-1] {
+		vk, ok := modifierKeys[name]
+		if !ok {
+			return nil, 0, fmt.Errorf("unknown modifier: %s", name)
+		}
+		mods = append(mods, vk)
+	}
+
+	if vk, ok := namedKeys[keyName]; ok {
+		return mods, vk, nil
+	}
+	if len(keyName) == 1 && (keyName[0] >= 'a' && keyName[0] <= 'z' || keyName[0] >= '0' && keyName[0] <= '9') {
+		return mods, uint16(strings.ToUpper(keyName)[0]), nil
+	}
+	return nil, 0, fmt.Errorf("unsupported key: %s", keyName)
+}
+
+// comboInputs presses the modifiers, taps the key and releases the
+// modifiers in reverse order.
+func comboInputs(mods []uint16, vk uint16) []keyboardInput {
+	var inputs []keyboardInput
+	for _, mod := range mods {
+		inputs = append(inputs, keyInput(mod, 0, 0))
+	}
+	inputs = append(inputs, keyInput(vk, 0, 0), keyInput(vk, 0, uint32(KEYEVENTF_KEYUP)))
+	for i := len(mods) - 1; i >= 0; i-- {
+		inputs = append(inputs, keyInput(mods[i], 0, uint32(KEYEVENTF_KEYUP)))
+	}
+	return inputs
+}
+
+func main() {
+	var windowTitle, method string
+	var targetFlags target.Flags
+	var delay, timeout time.Duration
+	var noFocus bool
+	flag.StringVar(&windowTitle, "title", "", "Window title to find and send the keys to")
+	targetFlags.Register()
+	flag.StringVar(&method, "method", "sendinput", "How to send the keys: sendinput (to the foreground window) or postmessage (no modifiers)")
+	flag.DurationVar(&delay, "delay", 12*time.Millisecond, "Delay after each key")
+	flag.BoolVar(&noFocus, "no-focus", false, "Do not focus the window before sending the keys with sendinput")
+	flag.DurationVar(&timeout, "timeout", time.Second, "How long to wait for the window to come to the foreground")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "Usage: gwc-key -title TITLE KEY... (e.g. ctrl+s, alt+f4, enter)")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() == 0 || method != "sendinput" && method != "postmessage" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	type combo struct {
+		mods []uint16
+		vk   uint16
+	}
+	var combos []combo
+	for _, arg := range flag.Args() {
+		mods, vk, err := parseKeyCombo(arg)
+		if err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+		if method == "postmessage" && len(mods) > 0 {
+			fmt.Println("Error: modifiers are not supported with -method postmessage")
+			os.Exit(1)
+		}
+		combos = append(combos, combo{mods, vk})
+	}
+
+	hwnd, err := target.Window(windowTitle, targetFlags, findWindow)
+	if err != nil {
+		fmt.Println("Error finding window:", err)
+		os.Exit(1)
+	}
+
+	if method == "sendinput" && !noFocus {
+		if err := focusWindow(hwnd, timeout); err != nil {
+			fmt.Println("Error focusing window:", err)
+			os.Exit(1)
+		}
+	}
+
+	for _, c := range combos {
+		if method == "postmessage" {
+			err = postKey(hwnd, c.vk)
+		} else {
+			err = sendInputs(comboInputs(c.mods, c.vk))
+		}
+		if err != nil {
+			fmt.Println("Error sending key:", err)
+			os.Exit(1)
+		}
+		time.Sleep(delay)
+	}
+}
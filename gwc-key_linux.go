@@ -0,0 +1,84 @@
+//go:build linux
+// +build linux
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/BurntSushi/xgb"
+	"gwctl/internal/keyboard"
+	"gwctl/internal/target"
+	"gwctl/internal/x11"
+)
+
+func main() {
+	var windowTitle, method string
+	var targetFlags target.Flags
+	var delay, timeout time.Duration
+	var noFocus bool
+	flag.StringVar(&windowTitle, "title", "", "Window title to find and send the keys to")
+	targetFlags.Register()
+	flag.StringVar(&method, "method", "xtest", "How to send the keys: xtest (to the focused window) or sendevent")
+	flag.DurationVar(&delay, "delay", 12*time.Millisecond, "Delay after each key")
+	flag.BoolVar(&noFocus, "no-focus", false, "Do not focus the window before sending the keys with xtest")
+	flag.DurationVar(&timeout, "timeout", time.Second, "How long to wait for the window to get the focus")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "Usage: gwc-key -title TITLE KEY... (e.g. ctrl+s, alt+f4, enter)")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() == 0 || method != "xtest" && method != "sendevent" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	var combos []keyboard.Combo
+	for _, arg := range flag.Args() {
+		c, err := keyboard.ParseCombo(arg)
+		if err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+		combos = append(combos, c)
+	}
+
+	conn, err := xgb.NewConn()
+	if err != nil {
+		fmt.Println("Error opening display:", err)
+		os.Exit(1)
+	}
+	defer conn.Close()
+
+	window, err := target.Window(conn, windowTitle, targetFlags, x11.FindWindow)
+	if err != nil {
+		fmt.Println("Error finding window:", err)
+		os.Exit(1)
+	}
+
+	if method == "xtest" && !noFocus {
+		if !x11.HasFocus(conn, window) && !x11.ActivateWindow(conn, window, timeout) {
+			fmt.Println("Error focusing window: window did not get the focus, try gwc-focuse first")
+			os.Exit(1)
+		}
+	}
+
+	kb, err := keyboard.New(conn, window, method == "xtest", delay)
+	if err != nil {
+		fmt.Println("Error:", err)
+		os.Exit(1)
+	}
+	defer kb.Close()
+
+	for _, c := range combos {
+		if err := kb.Tap(c); err != nil {
+			fmt.Println("Error sending key:", err)
+			kb.Close()
+			os.Exit(1)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"syscall"
+	"time"
+	"unsafe"
+
+	"gwctl/internal/target"
+)
+
+var (
+	modUser32                    = syscall.NewLazyDLL("user32.dll")
+	procFindWindow               = modUser32.NewProc("FindWindowW")
+	procGetWindowThreadProcessId = modUser32.NewProc("GetWindowThreadProcessId")
+	procIsWindow                 = modUser32.NewProc("IsWindow")
+)
+
+func findWindow(windowName *uint16) (syscall.Handle, error) {
+	ret, _, err := procFindWindow.Call(uintptr(unsafe.Pointer(nil)), uintptr(unsafe.Pointer(windowName)))
+	if ret == 0 {
+		return 0, err
+	}
+	return syscall.Handle(ret), nil
+}
+
+// killWindowProcess terminates the process that owns the window, without
+// giving it a chance to save anything. gwc-close is the graceful way.
+func killWindowProcess(hwnd syscall.Handle) (uint32, error) {
+	var pid uint32
+	procGetWindowThreadProcessId.Call(uintptr(hwnd), uintptr(unsafe.Pointer(&pid)))
+	if pid == 0 {
+		return 0, fmt.Errorf("failed to get window process")
+	}
+
+	process, err := syscall.OpenProcess(syscall.PROCESS_TERMINATE, false, pid)
+	if err != nil {
+		return pid, fmt.Errorf("failed to open process %d: %v", pid, err)
+	}
+	defer syscall.CloseHandle(process)
+
+	err = syscall.TerminateProcess(process, 1)
+	if err != nil {
+		return pid, fmt.Errorf("failed to terminate process %d: %v", pid, err)
+	}
+	return pid, nil
+}
+
+// waitForGone waits until the window no longer exists, or until timeout,
+// and reports whether it is gone.
+func waitForGone(hwnd syscall.Handle, timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for {
+		if ret, _, _ := procIsWindow.Call(uintptr(hwnd)); ret == 0 {
+			return true
+		}
+		if !time.Now().Before(deadline) {
+			return false
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+func main() {
+	var windowTitle string
+	var targetFlags target.Flags
+	var wait bool
+	var timeout time.Duration
+	flag.StringVar(&windowTitle, "title", "", "Window title to find and kill the process of")
+	targetFlags.Register()
+	flag.BoolVar(&wait, "wait", false, "Wait until the window is gone")
+	flag.DurationVar(&timeout, "timeout", 5*time.Second, "How long to wait with -wait")
+	flag.Parse()
+
+	hwnd, err := target.Window(windowTitle, targetFlags, findWindow)
+	if err != nil {
+		fmt.Println("Error finding window:", err)
+		os.Exit(1)
+	}
+
+	pid, err := killWindowProcess(hwnd)
+	if err != nil {
+		fmt.Println("Error killing window process:", err)
+		os.Exit(1)
+	}
+
+	if wait && !waitForGone(hwnd, timeout) {
+		fmt.Println("Window is still open.")
+		os.Exit(1)
+	}
+	fmt.Printf("Process %d killed.\n", pid)
+}
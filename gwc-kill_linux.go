@@ -0,0 +1,164 @@
+//go:build linux
+// +build linux
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/BurntSushi/xgb"
+	"github.com/BurntSushi/xgb/xproto"
+	"gwctl/internal/target"
+	"gwctl/internal/x11"
+)
+
+func getWindowPID(conn *xgb.Conn, window xproto.Window) uint32 {
+	pidAtom, err := x11.InternAtom(conn, "_NET_WM_PID")
+	if err != nil {
+		return 0
+	}
+	reply, err := xproto.GetProperty(conn, false, window,
+		pidAtom, xproto.AtomCardinal, 0, 1).Reply()
+	if err != nil || reply == nil || len(reply.Value) < 4 {
+		return 0
+	}
+	return xgb.Get32(reply.Value)
+}
+
+// isLocalClient reports whether the window belongs to a client on this
+// machine, so that its _NET_WM_PID refers to a local process.
+func isLocalClient(conn *xgb.Conn, window xproto.Window) bool {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return false
+	}
+	reply, err := xproto.GetProperty(conn, false, window,
+		xproto.AtomWmClientMachine, xproto.AtomString, 0, (1<<32)-1).Reply()
+	if err != nil || reply == nil || reply.ValueLen == 0 {
+		return false
+	}
+	return strings.TrimRight(string(reply.Value), "\x00") == hostname
+}
+
+var signals = map[string]syscall.Signal{
+	"TERM": syscall.SIGTERM,
+	"KILL": syscall.SIGKILL,
+	"INT":  syscall.SIGINT,
+	"HUP":  syscall.SIGHUP,
+}
+
+// killWindowClient terminates the client owning the window, without giving
+// it a chance to save anything; gwc-close is the graceful way. A local
+// process is sent the signal, anything else is disconnected from the X
+// server with XKillClient.
+func killWindowClient(conn *xgb.Conn, window xproto.Window, sig syscall.Signal) (string, error) {
+	if pid := getWindowPID(conn, window); pid != 0 && isLocalClient(conn, window) {
+		if err := syscall.Kill(int(pid), sig); err != nil {
+			return "", fmt.Errorf("failed to signal process %d: %v", pid, err)
+		}
+		return fmt.Sprintf("Process %d sent SIG%s.", pid, sigName(sig)), nil
+	}
+
+	if err := xproto.KillClientChecked(conn, uint32(window)).Check(); err != nil {
+		return "", fmt.Errorf("failed to kill X client: %v", err)
+	}
+	return "X client killed.", nil
+}
+
+func sigName(sig syscall.Signal) string {
+	for name, s := range signals {
+		if s == sig {
+			return name
+		}
+	}
+	return fmt.Sprint(int(sig))
+}
+
+// waitForGone waits until the window is destroyed or withdrawn, or until
+// timeout, and reports whether it is gone. Being unmapped does not count:
+// iconified windows and windows on other desktops are unmapped too. A
+// window that is only withdrawn loses its WM_STATE, which is what some
+// applications do when asked to close.
+func waitForGone(conn *xgb.Conn, window xproto.Window, timeout time.Duration) bool {
+	wmState, err := x11.InternAtom(conn, "WM_STATE")
+	if err != nil {
+		return false
+	}
+	// A frame or other window without WM_STATE can only be gone by being
+	// destroyed.
+	_, managed := windowState(conn, window, wmState)
+
+	deadline := time.Now().Add(timeout)
+	for {
+		exists, hasState := windowState(conn, window, wmState)
+		if !exists || managed && !hasState {
+			return true
+		}
+		if !time.Now().Before(deadline) {
+			return false
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// windowState reports whether the window exists and whether it has
+// WM_STATE. Errors other than BadWindow leave the window counted as
+// existing.
+func windowState(conn *xgb.Conn, window xproto.Window, wmState xproto.Atom) (exists, hasState bool) {
+	reply, err := xproto.GetProperty(conn, false, window,
+		wmState, xproto.GetPropertyTypeAny, 0, 0).Reply()
+	if err != nil {
+		_, bad := err.(xproto.WindowError)
+		return !bad, true
+	}
+	return true, reply.Format != 0
+}
+
+func main() {
+	var windowTitle, signal string
+	var targetFlags target.Flags
+	var wait bool
+	var timeout time.Duration
+	flag.StringVar(&windowTitle, "title", "", "Window title to find and kill the client of")
+	targetFlags.Register()
+	flag.StringVar(&signal, "signal", "KILL", "Signal for a local process: TERM, KILL, INT or HUP")
+	flag.BoolVar(&wait, "wait", false, "Wait until the window is gone")
+	flag.DurationVar(&timeout, "timeout", 5*time.Second, "How long to wait with -wait")
+	flag.Parse()
+
+	sig, ok := signals[strings.TrimPrefix(strings.ToUpper(signal), "SIG")]
+	if !ok {
+		fmt.Println("Unknown signal:", signal)
+		os.Exit(1)
+	}
+
+	conn, err := xgb.NewConn()
+	if err != nil {
+		fmt.Println("Error opening display:", err)
+		os.Exit(1)
+	}
+	defer conn.Close()
+
+	window, err := target.Window(conn, windowTitle, targetFlags, x11.FindWindow)
+	if err != nil {
+		fmt.Println("Error finding window:", err)
+		os.Exit(1)
+	}
+
+	result, err := killWindowClient(conn, window, sig)
+	if err != nil {
+		fmt.Println("Error killing window client:", err)
+		os.Exit(1)
+	}
+
+	if wait && !waitForGone(conn, window, timeout) {
+		fmt.Println("Window is still open.")
+		os.Exit(1)
+	}
+	fmt.Println(result)
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"syscall"
+	"unsafe"
+
+	"gwctl/internal/target"
+)
+
+var (
+	modUser32        = syscall.NewLazyDLL("user32.dll")
+	procFindWindow   = modUser32.NewProc("FindWindowW")
+	procSetWindowPos = modUser32.NewProc("SetWindowPos")
+	HWND_BOTTOM      = 1
+	SWP_NOSIZE       = 0x0001
+	SWP_NOMOVE       = 0x0002
+	SWP_NOACTIVATE   = 0x0010
+)
+
+func findWindow(windowName *uint16) (syscall.Handle, error) {
+	ret, _, err := procFindWindow.Call(uintptr(unsafe.Pointer(nil)), uintptr(unsafe.Pointer(windowName)))
+	if ret == 0 {
+		return 0, err
+	}
+	return syscall.Handle(ret), nil
+}
+
+func setWindowZOrder(hwnd syscall.Handle, insertAfter int) error {
+	ret, _, err := procSetWindowPos.Call(
+		uintptr(hwnd),
+		uintptr(insertAfter),
+		0,
+		0,
+		0,
+		0,
+		uintptr(SWP_NOMOVE|SWP_NOSIZE|SWP_NOACTIVATE),
+	)
+	if ret == 0 {
+		return fmt.Errorf("failed to set window position: %v", err)
+	}
+	return nil
+}
+
+func main() {
+	var windowTitle string
+	var targetFlags target.Flags
+	flag.StringVar(&windowTitle, "title", "", "Window title to find and lower")
+	targetFlags.Register()
+	flag.Parse()
+
+	hwnd, err := target.Window(windowTitle, targetFlags, findWindow)
+	if err != nil {
+		fmt.Println("Error finding window:", err)
+		os.Exit(1)
+	}
+
+	err = setWindowZOrder(hwnd, HWND_BOTTOM)
+	if err != nil {
+		fmt.Println("Error lowering window:", err)
+		os.Exit(1)
+	}
+
+	fmt.Println("Window lowered.")
+}
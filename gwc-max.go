@@ -3,15 +3,20 @@ package main
 import (
 	"flag"
 	"fmt"
+	"os"
 	"syscall"
 	"unsafe"
+
+	"gwctl/internal/target"
 )
 
 var (
 	modUser32      = syscall.NewLazyDLL("user32.dll")
 	procFindWindow = modUser32.NewProc("FindWindowW")
 	procShowWindow = modUser32.NewProc("ShowWindow")
+	procIsZoomed   = modUser32.NewProc("IsZoomed")
 	SW_MAXIMIZE    = 3
+	SW_RESTORE     = 9
 )
 
 func findWindow(windowName *uint16) (syscall.Handle, error) {
@@ -22,22 +27,60 @@ func findWindow(windowName *uint16) (syscall.Handle, error) {
 	return syscall.Handle(ret), nil
 }
 
+func isMaximized(hwnd syscall.Handle) bool {
+	ret, _, _ := procIsZoomed.Call(uintptr(hwnd))
+	return ret != 0
+}
+
 func maximizeWindow(hwnd syscall.Handle) {
 	procShowWindow.Call(uintptr(hwnd), uintptr(SW_MAXIMIZE))
 }
 
+func restoreWindow(hwnd syscall.Handle) {
+	procShowWindow.Call(uintptr(hwnd), uintptr(SW_RESTORE))
+}
+
 func main() {
 	var windowTitle string
+	var targetFlags target.Flags
 	flag.StringVar(&windowTitle, "title", "", "Window title to find and maximize")
+	targetFlags.Register()
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "Usage: gwc-max -title TITLE [toggle]")
+		flag.PrintDefaults()
+	}
 	flag.Parse()
 
-	windowName := syscall.StringToUTF16Ptr(windowTitle)
+	toggle := false
+	switch {
+	case flag.NArg() == 0:
+	case flag.NArg() == 1 && flag.Arg(0) == "toggle":
+		toggle = true
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
 
-	hwnd, err := findWindow(windowName)
+	hwnd, err := target.Window(windowTitle, targetFlags, findWindow)
 	if err != nil {
 		fmt.Println("Error finding window:", err)
+		os.Exit(1)
+	}
+
+	if !toggle {
+		maximizeWindow(hwnd)
 		return
 	}
 
-	maximizeWindow(hwnd)
+	if isMaximized(hwnd) {
+		restoreWindow(hwnd)
+	} else {
+		maximizeWindow(hwnd)
+	}
+
+	if isMaximized(hwnd) {
+		fmt.Println("Window is maximized.")
+	} else {
+		fmt.Println("Window is restored.")
+	}
 }
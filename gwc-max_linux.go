@@ -0,0 +1,109 @@
+//go:build linux
+// +build linux
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/BurntSushi/xgb"
+	"github.com/BurntSushi/xgb/xproto"
+	"gwctl/internal/target"
+	"gwctl/internal/x11"
+)
+
+const (
+	iconicState = 3
+)
+
+func isMaximized(conn *xgb.Conn, window xproto.Window) bool {
+	return x11.HasWindowState(conn, window, "_NET_WM_STATE_MAXIMIZED_VERT") &&
+		x11.HasWindowState(conn, window, "_NET_WM_STATE_MAXIMIZED_HORZ")
+}
+
+// setMaximized changes both maximized states in a single _NET_WM_STATE
+// message, so the window manager applies them together.
+func setMaximized(conn *xgb.Conn, window xproto.Window, maximized bool) error {
+	vert, err := x11.InternAtom(conn, "_NET_WM_STATE_MAXIMIZED_VERT")
+	if err != nil {
+		return err
+	}
+	horz, err := x11.InternAtom(conn, "_NET_WM_STATE_MAXIMIZED_HORZ")
+	if err != nil {
+		return err
+	}
+
+	action := uint32(x11.StateRemove)
+	if maximized {
+		action = x11.StateAdd
+	}
+	return x11.SendClientMessage(conn, window, "_NET_WM_STATE", action, uint32(vert), uint32(horz), x11.SourcePager)
+}
+
+// waitForMaximized waits until the window is maximized or restored as
+// wanted, or until timeout, and returns the state it ended up in.
+func waitForMaximized(conn *xgb.Conn, window xproto.Window, want bool, timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for {
+		maximized := isMaximized(conn, window)
+		if maximized == want || !time.Now().Before(deadline) {
+			return maximized
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func main() {
+	var windowTitle string
+	var targetFlags target.Flags
+	var timeout time.Duration
+	flag.StringVar(&windowTitle, "title", "", "Window title to find and maximize")
+	targetFlags.Register()
+	flag.DurationVar(&timeout, "timeout", time.Second, "How long to wait for the window manager with toggle")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "Usage: gwc-max -title TITLE [toggle]")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	toggle := false
+	switch {
+	case flag.NArg() == 0:
+	case flag.NArg() == 1 && flag.Arg(0) == "toggle":
+		toggle = true
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	conn, err := xgb.NewConn()
+	if err != nil {
+		fmt.Println("Error opening display:", err)
+		os.Exit(1)
+	}
+	defer conn.Close()
+
+	window, err := target.Window(conn, windowTitle, targetFlags, x11.FindWindow)
+	if err != nil {
+		fmt.Println("Error finding window:", err)
+		os.Exit(1)
+	}
+
+	maximize := !toggle || !isMaximized(conn, window)
+	err = setMaximized(conn, window, maximize)
+	if err != nil {
+		fmt.Println("Error changing window state:", err)
+		os.Exit(1)
+	}
+
+	if toggle {
+		if waitForMaximized(conn, window, maximize, timeout) {
+			fmt.Println("Window is maximized.")
+		} else {
+			fmt.Println("Window is restored.")
+		}
+	}
+}
@@ -3,15 +3,20 @@ package main
 import (
 	"flag"
 	"fmt"
+	"os"
 	"syscall"
 	"unsafe"
+
+	"gwctl/internal/target"
 )
 
 var (
 	modUser32      = syscall.NewLazyDLL("user32.dll")
 	procFindWindow = modUser32.NewProc("FindWindowW")
 	procShowWindow = modUser32.NewProc("ShowWindow")
+	procIsIconic   = modUser32.NewProc("IsIconic")
 	SW_MINIMIZE    = 6
+	SW_RESTORE     = 9
 )
 
 func findWindow(windowName *uint16) (syscall.Handle, error) {
@@ -22,22 +27,60 @@ func findWindow(windowName *uint16) (syscall.Handle, error) {
 	return syscall.Handle(ret), nil
 }
 
+func isMinimized(hwnd syscall.Handle) bool {
+	ret, _, _ := procIsIconic.Call(uintptr(hwnd))
+	return ret != 0
+}
+
 func minimizeWindow(hwnd syscall.Handle) {
 	procShowWindow.Call(uintptr(hwnd), uintptr(SW_MINIMIZE))
 }
 
+func restoreWindow(hwnd syscall.Handle) {
+	procShowWindow.Call(uintptr(hwnd), uintptr(SW_RESTORE))
+}
+
 func main() {
 	var windowTitle string
+	var targetFlags target.Flags
 	flag.StringVar(&windowTitle, "title", "", "Window title to minimize")
+	targetFlags.Register()
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "Usage: gwc-min -title TITLE [toggle]")
+		flag.PrintDefaults()
+	}
 	flag.Parse()
 
-	windowName := syscall.StringToUTF16Ptr(windowTitle)
+	toggle := false
+	switch {
+	case flag.NArg() == 0:
+	case flag.NArg() == 1 && flag.Arg(0) == "toggle":
+		toggle = true
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
 
-	hwnd, err := findWindow(windowName)
+	hwnd, err := target.Window(windowTitle, targetFlags, findWindow)
 	if err != nil {
 		fmt.Println("Error finding window:", err)
+		os.Exit(1)
+	}
+
+	if !toggle {
+		minimizeWindow(hwnd)
 		return
 	}
 
-	minimizeWindow(hwnd)
+	if isMinimized(hwnd) {
+		restoreWindow(hwnd)
+	} else {
+		minimizeWindow(hwnd)
+	}
+
+	if isMinimized(hwnd) {
+		fmt.Println("Window is minimized.")
+	} else {
+		fmt.Println("Window is restored.")
+	}
 }
@@ -0,0 +1,111 @@
+//go:build linux
+// +build linux
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/BurntSushi/xgb"
+	"github.com/BurntSushi/xgb/xproto"
+	"gwctl/internal/target"
+	"gwctl/internal/x11"
+)
+
+const (
+	iconicState = 3
+)
+
+func isMinimized(conn *xgb.Conn, window xproto.Window) bool {
+	if x11.HasWindowState(conn, window, "_NET_WM_STATE_HIDDEN") {
+		return true
+	}
+	wmState, err := x11.InternAtom(conn, "WM_STATE")
+	if err != nil {
+		return false
+	}
+	reply, err := xproto.GetProperty(conn, false, window, wmState, wmState, 0, 1).Reply()
+	return err == nil && len(reply.Value) >= 4 && xgb.Get32(reply.Value) == iconicState
+}
+
+func minimizeWindow(conn *xgb.Conn, window xproto.Window) error {
+	return x11.SendClientMessage(conn, window, "WM_CHANGE_STATE", iconicState)
+}
+
+// restoreWindow activates the window, which window managers take as the
+// request to deiconify it.
+func restoreWindow(conn *xgb.Conn, window xproto.Window) error {
+	return x11.SendClientMessage(conn, window, "_NET_ACTIVE_WINDOW", x11.SourcePager, uint32(xproto.TimeCurrentTime))
+}
+
+// waitForMinimized waits until the window is minimized or restored as
+// wanted, or until timeout, and returns the state it ended up in.
+func waitForMinimized(conn *xgb.Conn, window xproto.Window, want bool, timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for {
+		minimized := isMinimized(conn, window)
+		if minimized == want || !time.Now().Before(deadline) {
+			return minimized
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func main() {
+	var windowTitle string
+	var targetFlags target.Flags
+	var timeout time.Duration
+	flag.StringVar(&windowTitle, "title", "", "Window title to minimize")
+	targetFlags.Register()
+	flag.DurationVar(&timeout, "timeout", time.Second, "How long to wait for the window manager with toggle")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "Usage: gwc-min -title TITLE [toggle]")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	toggle := false
+	switch {
+	case flag.NArg() == 0:
+	case flag.NArg() == 1 && flag.Arg(0) == "toggle":
+		toggle = true
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	conn, err := xgb.NewConn()
+	if err != nil {
+		fmt.Println("Error opening display:", err)
+		os.Exit(1)
+	}
+	defer conn.Close()
+
+	window, err := target.Window(conn, windowTitle, targetFlags, x11.FindWindow)
+	if err != nil {
+		fmt.Println("Error finding window:", err)
+		os.Exit(1)
+	}
+
+	minimize := !toggle || !isMinimized(conn, window)
+	if minimize {
+		err = minimizeWindow(conn, window)
+	} else {
+		err = restoreWindow(conn, window)
+	}
+	if err != nil {
+		fmt.Println("Error changing window state:", err)
+		os.Exit(1)
+	}
+
+	if toggle {
+		if waitForMinimized(conn, window, minimize, timeout) {
+			fmt.Println("Window is minimized.")
+		} else {
+			fmt.Println("Window is restored.")
+		}
+	}
+}
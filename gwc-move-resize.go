@@ -3,8 +3,11 @@ package main
 import (
 	"flag"
 	"fmt"
+	"os"
 	"syscall"
 	"unsafe"
+
+	"gwctl/internal/target"
 )
 
 var (
@@ -27,20 +30,20 @@ func moveResizeWindow(hwnd syscall.Handle, x, y, width, height int32) {
 
 func main() {
 	var windowTitle string
+	var targetFlags target.Flags
 	var x, y, width, height int
 	flag.StringVar(&windowTitle, "title", "", "Window title to find and move/resize")
+	targetFlags.Register()
 	flag.IntVar(&x, "x", 0, "X position")
 	flag.IntVar(&y, "y", 0, "Y position")
 	flag.IntVar(&width, "width", 800, "Width")
 	flag.IntVar(&height, "height", 600, "Height")
 	flag.Parse()
 
-	windowName := syscall.StringToUTF16Ptr(windowTitle)
-
-	hwnd, err := findWindow(windowName)
+	hwnd, err := target.Window(windowTitle, targetFlags, findWindow)
 	if err != nil {
 		fmt.Println("Error finding window:", err)
-		return
+		os.Exit(1)
 	}
 
 	moveResizeWindow(hwnd, int32(x), int32(y), int32(width), int32(height))
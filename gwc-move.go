@@ -3,8 +3,11 @@ package main
 import (
 	"flag"
 	"fmt"
+	"os"
 	"syscall"
 	"unsafe"
+
+	"gwctl/internal/target"
 )
 
 var (
@@ -50,24 +53,24 @@ func setWindowPos(hwnd syscall.Handle, x, y, width, height int) {
 
 func main() {
 	var windowTitle string
+	var targetFlags target.Flags
 	var x, y int
 	flag.StringVar(&windowTitle, "title", "", "Window title to set position")
+	targetFlags.Register()
 	flag.IntVar(&x, "x", 0, "Specifies the x-coordinate of the window")
 	flag.IntVar(&y, "y", 0, "Specifies the y-coordinate of the window")
 	flag.Parse()
 
-	windowName := syscall.StringToUTF16Ptr(windowTitle)
-
-	hwnd, err := findWindow(windowName)
+	hwnd, err := target.Window(windowTitle, targetFlags, findWindow)
 	if err != nil {
 		fmt.Println("Error finding window:", err)
-		return
+		os.Exit(1)
 	}
 
 	left, top, right, bottom, err := getWindowRect(hwnd)
 	if err != nil {
 		fmt.Println("Error getting window rect:", err)
-		return
+		os.Exit(1)
 	}
 	width := right - left
 	height := bottom - top
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"syscall"
+	"unsafe"
+
+	"gwctl/internal/target"
+)
+
+var (
+	modUser32                      = syscall.NewLazyDLL("user32.dll")
+	procFindWindow                 = modUser32.NewProc("FindWindowW")
+	procGetWindowLong              = modUser32.NewProc("GetWindowLongW")
+	procSetWindowLong              = modUser32.NewProc("SetWindowLongW")
+	procGetLayeredWindowAttributes = modUser32.NewProc("GetLayeredWindowAttributes")
+	procSetLayeredWindowAttributes = modUser32.NewProc("SetLayeredWindowAttributes")
+	GWL_EXSTYLE                    = -20
+	WS_EX_LAYERED                  = 0x00080000
+	LWA_ALPHA                      = 0x00000002
+)
+
+func findWindow(windowName *uint16) (syscall.Handle, error) {
+	ret, _, err := procFindWindow.Call(uintptr(unsafe.Pointer(nil)), uintptr(unsafe.Pointer(windowName)))
+	if ret == 0 {
+		return 0, err
+	}
+	return syscall.Handle(ret), nil
+}
+
+// getOpacity returns the window opacity between 0 and 1. Windows without
+// WS_EX_LAYERED, or layered by color key only, are fully opaque.
+func getOpacity(hwnd syscall.Handle) float64 {
+	style, _, _ := procGetWindowLong.Call(uintptr(hwnd), uintptr(GWL_EXSTYLE))
+	if style&uintptr(WS_EX_LAYERED) == 0 {
+		return 1
+	}
+
+	var alpha byte
+	var flags uint32
+	ret, _, _ := procGetLayeredWindowAttributes.Call(uintptr(hwnd), 0,
+		uintptr(unsafe.Pointer(&alpha)), uintptr(unsafe.Pointer(&flags)))
+	if ret == 0 || flags&uint32(LWA_ALPHA) == 0 {
+		return 1
+	}
+	return float64(alpha) / 255
+}
+
+func setOpacity(hwnd syscall.Handle, opacity float64) error {
+	style, _, _ := procGetWindowLong.Call(uintptr(hwnd), uintptr(GWL_EXSTYLE))
+
+	// A fully opaque window does not need to be layered, which is cheaper to draw.
+	if opacity >= 1 {
+		if style&uintptr(WS_EX_LAYERED) != 0 {
+			procSetLayeredWindowAttributes.Call(uintptr(hwnd), 0, 255, uintptr(LWA_ALPHA))
+			procSetWindowLong.Call(uintptr(hwnd), uintptr(GWL_EXSTYLE), style&^uintptr(WS_EX_LAYERED))
+		}
+		return nil
+	}
+
+	if style&uintptr(WS_EX_LAYERED) == 0 {
+		procSetWindowLong.Call(uintptr(hwnd), uintptr(GWL_EXSTYLE), style|uintptr(WS_EX_LAYERED))
+	}
+
+	alpha := byte(math.Round(opacity * 255))
+	ret, _, err := procSetLayeredWindowAttributes.Call(uintptr(hwnd), 0, uintptr(alpha), uintptr(LWA_ALPHA))
+	if ret == 0 {
+		return fmt.Errorf("failed to set window opacity: %v", err)
+	}
+	return nil
+}
+
+func main() {
+	var windowTitle string
+	var targetFlags target.Flags
+	var value, altValue float64
+	flag.StringVar(&windowTitle, "title", "", "Window title to find and change the opacity of")
+	targetFlags.Register()
+	flag.Float64Var(&value, "value", 1, "Opacity between 0 (transparent) and 1 (opaque)")
+	flag.Float64Var(&altValue, "alt", 1, "Opacity to toggle to when the window already has -value")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "Usage: gwc-opacity -title TITLE [-value 0.8] [-alt 1] [set|toggle|get]")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	action := "set"
+	if flag.NArg() > 0 {
+		action = flag.Arg(0)
+	}
+
+	if value < 0 || value > 1 || altValue < 0 || altValue > 1 {
+		fmt.Println("Opacity values must be between 0 and 1.")
+		os.Exit(1)
+	}
+
+	hwnd, err := target.Window(windowTitle, targetFlags, findWindow)
+	if err != nil {
+		fmt.Println("Error finding window:", err)
+		os.Exit(1)
+	}
+
+	switch action {
+	case "get":
+		fmt.Printf("%.2f\n", getOpacity(hwnd))
+		return
+	case "toggle":
+		// Alpha is stored in 1/255 steps, so compare with that tolerance.
+		if math.Abs(getOpacity(hwnd)-value) < 1.0/255 {
+			value = altValue
+		}
+	case "set":
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	err = setOpacity(hwnd, value)
+	if err != nil {
+		fmt.Println("Error setting window opacity:", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Window opacity set to %.2f.\n", getOpacity(hwnd))
+}
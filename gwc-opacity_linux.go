@@ -0,0 +1,121 @@
+//go:build linux
+// +build linux
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math"
+	"os"
+
+	"github.com/BurntSushi/xgb"
+	"github.com/BurntSushi/xgb/xproto"
+	"gwctl/internal/target"
+	"gwctl/internal/x11"
+)
+
+// opaque is the _NET_WM_WINDOW_OPACITY value of a fully opaque window.
+const opaque = 0xffffffff
+
+// getOpacity returns the window opacity between 0 and 1. Windows without
+// _NET_WM_WINDOW_OPACITY are fully opaque.
+func getOpacity(conn *xgb.Conn, window xproto.Window) (float64, error) {
+	atom, err := x11.InternAtom(conn, "_NET_WM_WINDOW_OPACITY")
+	if err != nil {
+		return 0, err
+	}
+	reply, err := xproto.GetProperty(conn, false, window,
+		atom, xproto.AtomCardinal, 0, 1).Reply()
+	if err != nil {
+		return 0, err
+	}
+	if len(reply.Value) < 4 {
+		return 1, nil
+	}
+	return float64(xgb.Get32(reply.Value)) / opaque, nil
+}
+
+// setOpacity sets _NET_WM_WINDOW_OPACITY, which the compositing manager
+// applies to the window. A fully opaque window gets the property removed.
+func setOpacity(conn *xgb.Conn, window xproto.Window, opacity float64) error {
+	atom, err := x11.InternAtom(conn, "_NET_WM_WINDOW_OPACITY")
+	if err != nil {
+		return err
+	}
+
+	if opacity >= 1 {
+		return xproto.DeletePropertyChecked(conn, window, atom).Check()
+	}
+
+	buf := make([]byte, 4)
+	xgb.Put32(buf, uint32(math.Round(opacity*opaque)))
+	return xproto.ChangePropertyChecked(conn, xproto.PropModeReplace, window,
+		atom, xproto.AtomCardinal, 32, 1, buf).Check()
+}
+
+func main() {
+	var windowTitle string
+	var targetFlags target.Flags
+	var value, altValue float64
+	flag.StringVar(&windowTitle, "title", "", "Window title to find and change the opacity of")
+	targetFlags.Register()
+	flag.Float64Var(&value, "value", 1, "Opacity between 0 (transparent) and 1 (opaque)")
+	flag.Float64Var(&altValue, "alt", 1, "Opacity to toggle to when the window already has -value")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "Usage: gwc-opacity -title TITLE [-value 0.8] [-alt 1] [set|toggle|get]")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	action := "set"
+	if flag.NArg() > 0 {
+		action = flag.Arg(0)
+	}
+
+	if value < 0 || value > 1 || altValue < 0 || altValue > 1 {
+		fmt.Println("Opacity values must be between 0 and 1.")
+		os.Exit(1)
+	}
+
+	conn, err := xgb.NewConn()
+	if err != nil {
+		fmt.Println("Error opening display:", err)
+		os.Exit(1)
+	}
+	defer conn.Close()
+
+	window, err := target.Window(conn, windowTitle, targetFlags, x11.FindWindow)
+	if err != nil {
+		fmt.Println("Error finding window:", err)
+		os.Exit(1)
+	}
+
+	current, err := getOpacity(conn, window)
+	if err != nil {
+		fmt.Println("Error reading window opacity:", err)
+		os.Exit(1)
+	}
+
+	switch action {
+	case "get":
+		fmt.Printf("%.2f\n", current)
+		return
+	case "toggle":
+		if math.Abs(current-value) < 0.005 {
+			value = altValue
+		}
+	case "set":
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	err = setOpacity(conn, window, value)
+	if err != nil {
+		fmt.Println("Error setting window opacity:", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Window opacity set to %.2f.\n", value)
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"runtime"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	modUser32               = syscall.NewLazyDLL("user32.dll")
+	procSetWindowsHookEx    = modUser32.NewProc("SetWindowsHookExW")
+	procUnhookWindowsHookEx = modUser32.NewProc("UnhookWindowsHookEx")
+	procCallNextHookEx      = modUser32.NewProc("CallNextHookEx")
+	procGetMessage          = modUser32.NewProc("GetMessageW")
+	procPostQuitMessage     = modUser32.NewProc("PostQuitMessage")
+	procWindowFromPoint     = modUser32.NewProc("WindowFromPoint")
+	procGetAncestor         = modUser32.NewProc("GetAncestor")
+	procGetWindowText       = modUser32.NewProc("GetWindowTextW")
+	procGetClassName        = modUser32.NewProc("GetClassNameW")
+	modKernel32             = syscall.NewLazyDLL("kernel32.dll")
+	procGetModuleHandle     = modKernel32.NewProc("GetModuleHandleW")
+	WH_MOUSE_LL             = 14
+	HC_ACTION               = 0
+	WM_LBUTTONDOWN          = 0x0201
+	WM_LBUTTONUP            = 0x0202
+	WM_RBUTTONDOWN          = 0x0204
+	WM_RBUTTONUP            = 0x0205
+	GA_ROOT                 = 2
+)
+
+type point struct {
+	x, y int32
+}
+
+type msllHookStruct struct {
+	pt        point
+	mouseData uint32
+	flags     uint32
+	time      uint32
+	extraInfo uintptr
+}
+
+type msg struct {
+	hwnd    uintptr
+	message uint32
+	wParam  uintptr
+	lParam  uintptr
+	time    uint32
+	pt      point
+}
+
+func getWindowText(hwnd syscall.Handle) string {
+	buf := make([]uint16, 512)
+	procGetWindowText.Call(uintptr(hwnd), uintptr(unsafe.Pointer(&buf[0])), uintptr(len(buf)))
+	return syscall.UTF16ToString(buf)
+}
+
+func getClassName(hwnd syscall.Handle) string {
+	buf := make([]uint16, 256)
+	procGetClassName.Call(uintptr(hwnd), uintptr(unsafe.Pointer(&buf[0])), uintptr(len(buf)))
+	return syscall.UTF16ToString(buf)
+}
+
+func windowFromPoint(pt point) syscall.Handle {
+	// POINT is passed by value, packed into a single argument on 64-bit.
+	var child uintptr
+	if unsafe.Sizeof(uintptr(0)) == 8 {
+		child, _, _ = procWindowFromPoint.Call(uintptr(uint32(pt.x)) | uintptr(uint32(pt.y))<<32)
+	} else {
+		child, _, _ = procWindowFromPoint.Call(uintptr(pt.x), uintptr(pt.y))
+	}
+	hwnd, _, _ := procGetAncestor.Call(child, uintptr(GA_ROOT))
+	return syscall.Handle(hwnd)
+}
+
+// pickWindow installs a low-level mouse hook and returns the top-level
+// window the user clicks with the left button. The right button cancels.
+// Both clicks are swallowed so they do not reach the window.
+func pickWindow() (syscall.Handle, error) {
+	// The hook is called on the thread that installed it, while it pumps
+	// messages.
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	var clicked *point
+	var cancelled bool
+
+	callback := syscall.NewCallback(func(nCode int, wParam uintptr, info *msllHookStruct) uintptr {
+		if nCode == HC_ACTION {
+			switch int(wParam) {
+			case WM_LBUTTONDOWN:
+				pt := info.pt
+				clicked = &pt
+				return 1
+			case WM_RBUTTONDOWN:
+				cancelled = true
+				return 1
+			case WM_LBUTTONUP, WM_RBUTTONUP:
+				if clicked != nil || cancelled {
+					procPostQuitMessage.Call(0)
+					return 1
+				}
+			}
+		}
+		ret, _, _ := procCallNextHookEx.Call(0, uintptr(nCode), wParam, uintptr(unsafe.Pointer(info)))
+		return ret
+	})
+
+	module, _, _ := procGetModuleHandle.Call(0)
+	hook, _, err := procSetWindowsHookEx.Call(uintptr(WH_MOUSE_LL), callback, module, 0)
+	if hook == 0 {
+		return 0, fmt.Errorf("failed to install mouse hook: %v", err)
+	}
+	defer procUnhookWindowsHookEx.Call(hook)
+
+	var m msg
+	for {
+		ret, _, _ := procGetMessage.Call(uintptr(unsafe.Pointer(&m)), 0, 0, 0)
+		if int32(ret) <= 0 {
+			break
+		}
+	}
+
+	if cancelled || clicked == nil {
+		return 0, fmt.Errorf("cancelled")
+	}
+	hwnd := windowFromPoint(*clicked)
+	if hwnd == 0 {
+		return 0, fmt.Errorf("no window under the pointer")
+	}
+	return hwnd, nil
+}
+
+func main() {
+	var idOnly bool
+	flag.BoolVar(&idOnly, "id", false, "Only print the window handle, for use by other commands")
+	flag.Parse()
+
+	fmt.Fprintln(os.Stderr, "Click a window to select it, or right-click to cancel.")
+
+	hwnd, err := pickWindow()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error picking window:", err)
+		os.Exit(1)
+	}
+
+	// With -id the details still reach the user, but stdout carries only
+	// the handle for the command that ran us.
+	details := os.Stdout
+	if idOnly {
+		fmt.Printf("0x%x\n", uintptr(hwnd))
+		details = os.Stderr
+	}
+	fmt.Fprintf(details, "handle: 0x%x\n", uintptr(hwnd))
+	fmt.Fprintf(details, "title:  %s\n", getWindowText(hwnd))
+	fmt.Fprintf(details, "class:  %s\n", getClassName(hwnd))
+}
@@ -0,0 +1,125 @@
+//go:build linux
+// +build linux
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/BurntSushi/xgb"
+	"github.com/BurntSushi/xgb/xproto"
+	"gwctl/internal/target"
+	"gwctl/internal/x11"
+)
+
+// crosshairCursor creates the crosshair from the standard cursor font.
+func crosshairCursor(conn *xgb.Conn) (xproto.Cursor, error) {
+	const xcCrosshair = 34
+
+	font, err := xproto.NewFontId(conn)
+	if err != nil {
+		return 0, err
+	}
+	err = xproto.OpenFontChecked(conn, font, uint16(len("cursor")), "cursor").Check()
+	if err != nil {
+		return 0, err
+	}
+	defer xproto.CloseFont(conn, font)
+
+	cursor, err := xproto.NewCursorId(conn)
+	if err != nil {
+		return 0, err
+	}
+	err = xproto.CreateGlyphCursorChecked(conn, cursor, font, font,
+		xcCrosshair, xcCrosshair+1, 0, 0, 0, 0xffff, 0xffff, 0xffff).Check()
+	return cursor, err
+}
+
+// pickWindow grabs the pointer and returns the client window the user
+// clicks with the left button. Any other button cancels.
+func pickWindow(conn *xgb.Conn) (xproto.Window, error) {
+	root := xproto.Setup(conn).DefaultScreen(conn).Root
+
+	cursor, err := crosshairCursor(conn)
+	if err != nil {
+		cursor = 0
+	}
+
+	grab, err := xproto.GrabPointer(conn, false, root,
+		xproto.EventMaskButtonPress|xproto.EventMaskButtonRelease,
+		xproto.GrabModeAsync, xproto.GrabModeAsync, 0, cursor, xproto.TimeCurrentTime).Reply()
+	if err != nil {
+		return 0, fmt.Errorf("failed to grab pointer: %v", err)
+	}
+	if grab.Status != xproto.GrabStatusSuccess {
+		return 0, fmt.Errorf("failed to grab pointer, another program holds it")
+	}
+	defer xproto.UngrabPointer(conn, xproto.TimeCurrentTime)
+
+	// The window is taken on press, but the grab is kept until the release
+	// so the click does not reach the window.
+	var picked xproto.Window
+	var button xproto.Button
+	for {
+		ev, err := conn.WaitForEvent()
+		if ev == nil && err == nil {
+			return 0, fmt.Errorf("connection closed")
+		}
+		switch e := ev.(type) {
+		case xproto.ButtonPressEvent:
+			if button == 0 {
+				button, picked = e.Detail, e.Child
+			}
+		case xproto.ButtonReleaseEvent:
+			if button == 0 || e.Detail != button {
+				continue
+			}
+			if button != xproto.ButtonIndex1 {
+				return 0, fmt.Errorf("cancelled")
+			}
+			if picked == 0 {
+				return 0, fmt.Errorf("no window under the pointer")
+			}
+			if client := target.ClientWindow(conn, picked); client != 0 {
+				return client, nil
+			}
+			return picked, nil
+		}
+	}
+}
+
+func main() {
+	var idOnly bool
+	flag.BoolVar(&idOnly, "id", false, "Only print the window ID, for use by other commands")
+	flag.Parse()
+
+	conn, err := xgb.NewConn()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error opening display:", err)
+		os.Exit(1)
+	}
+	defer conn.Close()
+
+	fmt.Fprintln(os.Stderr, "Click a window to select it, or another button to cancel.")
+
+	window, err := pickWindow(conn)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error picking window:", err)
+		conn.Close()
+		os.Exit(1)
+	}
+
+	// With -id the details still reach the user, but stdout carries only
+	// the ID for the command that ran us.
+	details := os.Stdout
+	if idOnly {
+		fmt.Printf("0x%x\n", uint32(window))
+		details = os.Stderr
+	}
+	fmt.Fprintf(details, "id:    0x%x\n", uint32(window))
+	fmt.Fprintf(details, "title: %s\n", x11.WindowName(conn, window))
+	fmt.Fprintf(details, "class: %s\n", strings.Join(x11.WindowClass(conn, window), ", "))
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"syscall"
+	"unsafe"
+
+	"gwctl/internal/target"
+)
+
+var (
+	modUser32           = syscall.NewLazyDLL("user32.dll")
+	procFindWindow      = modUser32.NewProc("FindWindowW")
+	procGetWindowRect   = modUser32.NewProc("GetWindowRect")
+	procSetCursorPos    = modUser32.NewProc("SetCursorPos")
+	procGetCursorPos    = modUser32.NewProc("GetCursorPos")
+	procWindowFromPoint = modUser32.NewProc("WindowFromPoint")
+	procGetAncestor     = modUser32.NewProc("GetAncestor")
+	procGetWindowText   = modUser32.NewProc("GetWindowTextW")
+	procGetClassName    = modUser32.NewProc("GetClassNameW")
+	GA_ROOT             = 2
+)
+
+type rect struct {
+	left, top, right, bottom int32
+}
+
+func findWindow(windowName *uint16) (syscall.Handle, error) {
+	ret, _, err := procFindWindow.Call(uintptr(unsafe.Pointer(nil)), uintptr(unsafe.Pointer(windowName)))
+	if ret == 0 {
+		return 0, err
+	}
+	return syscall.Handle(ret), nil
+}
+
+func getWindowRect(hwnd syscall.Handle) (rect, error) {
+	var r rect
+	ret, _, err := procGetWindowRect.Call(uintptr(hwnd), uintptr(unsafe.Pointer(&r)))
+	if ret == 0 {
+		return r, fmt.Errorf("failed to get window rect: %v", err)
+	}
+	return r, nil
+}
+
+// screenPoint turns a position relative to the window's top-left corner
+// into screen coordinates, or takes the window's center if center is set.
+func screenPoint(hwnd syscall.Handle, x, y int, center bool) (int32, int32, error) {
+	r, err := getWindowRect(hwnd)
+	if err != nil {
+		return 0, 0, err
+	}
+	if center {
+		return (r.left + r.right) / 2, (r.top + r.bottom) / 2, nil
+	}
+	return r.left + int32(x), r.top + int32(y), nil
+}
+
+func parsePoint(s string) (int, int, error) {
+	var x, y int
+	if _, err := fmt.Sscanf(s, "%d,%d", &x, &y); err != nil {
+		return 0, 0, fmt.Errorf("invalid position '%s', expected X,Y", s)
+	}
+	return x, y, nil
+}
+
+func warpPointer(x, y int32) error {
+	ret, _, err := procSetCursorPos.Call(uintptr(x), uintptr(y))
+	if ret == 0 {
+		return fmt.Errorf("failed to move pointer: %v", err)
+	}
+	return nil
+}
+
+func getWindowText(hwnd syscall.Handle) string {
+	buf := make([]uint16, 512)
+	procGetWindowText.Call(uintptr(hwnd), uintptr(unsafe.Pointer(&buf[0])), uintptr(len(buf)))
+	return syscall.UTF16ToString(buf)
+}
+
+func getClassName(hwnd syscall.Handle) string {
+	buf := make([]uint16, 256)
+	procGetClassName.Call(uintptr(hwnd), uintptr(unsafe.Pointer(&buf[0])), uintptr(len(buf)))
+	return syscall.UTF16ToString(buf)
+}
+
+// windowUnderPointer returns the top-level window under the pointer and
+// the pointer position on screen.
+func windowUnderPointer() (syscall.Handle, int32, int32, error) {
+	var pt struct {
+		x, y int32
+	}
+	ret, _, err := procGetCursorPos.Call(uintptr(unsafe.Pointer(&pt)))
+	if ret == 0 {
+		return 0, 0, 0, fmt.Errorf("failed to get pointer position: %v", err)
+	}
+
+	// POINT is passed by value, packed into a single argument on 64-bit.
+	var child uintptr
+	if unsafe.Sizeof(uintptr(0)) == 8 {
+		child, _, _ = procWindowFromPoint.Call(uintptr(uint32(pt.x)) | uintptr(uint32(pt.y))<<32)
+	} else {
+		child, _, _ = procWindowFromPoint.Call(uintptr(pt.x), uintptr(pt.y))
+	}
+	hwnd, _, _ := procGetAncestor.Call(child, uintptr(GA_ROOT))
+	return syscall.Handle(hwnd), pt.x, pt.y, nil
+}
+
+func main() {
+	var windowTitle, at string
+	var targetFlags target.Flags
+	var center bool
+	flag.StringVar(&windowTitle, "title", "", "Window title to find and move the pointer into (warp)")
+	targetFlags.Register()
+	flag.StringVar(&at, "at", "", "Position relative to the window's top-left corner, as X,Y (warp)")
+	flag.BoolVar(&center, "center", false, "Move the pointer to the center of the window (warp)")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "Usage: gwc-pointer where | gwc-pointer -title TITLE (-at X,Y | -center) warp")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	switch flag.Arg(0) {
+	case "where":
+		hwnd, x, y, err := windowUnderPointer()
+		if err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+		fmt.Printf("pointer:  %d,%d\n", x, y)
+		if hwnd == 0 {
+			return
+		}
+		r, _ := getWindowRect(hwnd)
+		fmt.Printf("handle:   0x%x\n", uintptr(hwnd))
+		fmt.Printf("title:    %s\n", getWindowText(hwnd))
+		fmt.Printf("class:    %s\n", getClassName(hwnd))
+		fmt.Printf("relative: %d,%d\n", x-r.left, y-r.top)
+	case "warp":
+		if (at == "") == !center {
+			fmt.Println("Please provide either -at X,Y or -center.")
+			os.Exit(1)
+		}
+		var x, y int
+		if at != "" {
+			var err error
+			if x, y, err = parsePoint(at); err != nil {
+				fmt.Println("Error:", err)
+				os.Exit(1)
+			}
+		}
+
+		hwnd, err := target.Window(windowTitle, targetFlags, findWindow)
+		if err != nil {
+			fmt.Println("Error finding window:", err)
+			os.Exit(1)
+		}
+
+		screenX, screenY, err := screenPoint(hwnd, x, y, center)
+		if err == nil {
+			err = warpPointer(screenX, screenY)
+		}
+		if err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+}
@@ -0,0 +1,131 @@
+//go:build linux
+// +build linux
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/BurntSushi/xgb"
+	"github.com/BurntSushi/xgb/xproto"
+	"gwctl/internal/target"
+	"gwctl/internal/x11"
+)
+
+func parsePoint(s string) (int, int, error) {
+	var x, y int
+	if _, err := fmt.Sscanf(s, "%d,%d", &x, &y); err != nil {
+		return 0, 0, fmt.Errorf("invalid position '%s', expected X,Y", s)
+	}
+	return x, y, nil
+}
+
+// rootPoint turns a position relative to the window's top-left corner
+// into root window coordinates, or takes the window's center if center is
+// set.
+func rootPoint(conn *xgb.Conn, window xproto.Window, x, y int, center bool) (int16, int16, error) {
+	if center {
+		geom, err := xproto.GetGeometry(conn, xproto.Drawable(window)).Reply()
+		if err != nil {
+			return 0, 0, fmt.Errorf("failed to get window geometry: %v", err)
+		}
+		x, y = int(geom.Width)/2, int(geom.Height)/2
+	}
+
+	root := xproto.Setup(conn).DefaultScreen(conn).Root
+	pos, err := xproto.TranslateCoordinates(conn, window, root, int16(x), int16(y)).Reply()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to get window position: %v", err)
+	}
+	return pos.DstX, pos.DstY, nil
+}
+
+func warpPointer(conn *xgb.Conn, x, y int16) error {
+	root := xproto.Setup(conn).DefaultScreen(conn).Root
+	err := xproto.WarpPointerChecked(conn, 0, root, 0, 0, 0, 0, x, y).Check()
+	if err != nil {
+		return fmt.Errorf("failed to move pointer: %v", err)
+	}
+	return nil
+}
+
+func main() {
+	var windowTitle, at string
+	var targetFlags target.Flags
+	var center bool
+	flag.StringVar(&windowTitle, "title", "", "Window title to find and move the pointer into (warp)")
+	targetFlags.Register()
+	flag.StringVar(&at, "at", "", "Position relative to the window's top-left corner, as X,Y (warp)")
+	flag.BoolVar(&center, "center", false, "Move the pointer to the center of the window (warp)")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "Usage: gwc-pointer where | gwc-pointer -title TITLE (-at X,Y | -center) warp")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	action := flag.Arg(0)
+	if action != "where" && action != "warp" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if action == "warp" && (at == "") == !center {
+		fmt.Println("Please provide either -at X,Y or -center.")
+		os.Exit(1)
+	}
+	var x, y int
+	if at != "" {
+		var err error
+		if x, y, err = parsePoint(at); err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+	}
+
+	conn, err := xgb.NewConn()
+	if err != nil {
+		fmt.Println("Error opening display:", err)
+		os.Exit(1)
+	}
+	defer conn.Close()
+
+	if action == "where" {
+		root := xproto.Setup(conn).DefaultScreen(conn).Root
+		pointer, err := xproto.QueryPointer(conn, root).Reply()
+		if err != nil {
+			fmt.Println("Error getting pointer position:", err)
+			os.Exit(1)
+		}
+		fmt.Printf("pointer:  %d,%d\n", pointer.RootX, pointer.RootY)
+
+		window := target.ClientWindow(conn, pointer.Child)
+		if pointer.Child == 0 || window == 0 {
+			return
+		}
+		pos, err := xproto.TranslateCoordinates(conn, root, window, pointer.RootX, pointer.RootY).Reply()
+		if err != nil {
+			fmt.Println("Error getting window position:", err)
+			os.Exit(1)
+		}
+		fmt.Printf("id:       0x%x\n", uint32(window))
+		fmt.Printf("title:    %s\n", x11.WindowName(conn, window))
+		fmt.Printf("relative: %d,%d\n", pos.DstX, pos.DstY)
+		return
+	}
+
+	window, err := target.Window(conn, windowTitle, targetFlags, x11.FindWindow)
+	if err != nil {
+		fmt.Println("Error finding window:", err)
+		os.Exit(1)
+	}
+
+	rootX, rootY, err := rootPoint(conn, window, x, y, center)
+	if err == nil {
+		err = warpPointer(conn, rootX, rootY)
+	}
+	if err != nil {
+		fmt.Println("Error:", err)
+		os.Exit(1)
+	}
+}
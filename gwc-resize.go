@@ -3,8 +3,11 @@ package main
 import (
 	"flag"
 	"fmt"
+	"os"
 	"syscall"
 	"unsafe"
+
+	"gwctl/internal/target"
 )
 
 var (
@@ -39,7 +42,7 @@ func resizeWindow(hwnd syscall.Handle, width, height int32) {
 	rect, err := getWindowRect(hwnd)
 	if err != nil {
 		fmt.Println("Error getting window rect:", err)
-		return
+		os.Exit(1)
 	}
 	x, y := rect.Left, rect.Top
 	procMoveWindow.Call(
@@ -54,23 +57,18 @@ func resizeWindow(hwnd syscall.Handle, width, height int32) {
 
 func main() {
 	var windowTitle string
+	var targetFlags target.Flags
 	var width, height int
 	flag.StringVar(&windowTitle, "title", "", "Window title to find and resize")
+	targetFlags.Register()
 	flag.IntVar(&width, "width", 800, "Width of the window")
 	flag.IntVar(&height, "height", 600, "Height of the window")
 	flag.Parse()
 
-	if windowTitle == "" {
-		fmt.Println("Please provide a window title using the -title flag.")
-		return
-	}
-
-	windowName := syscall.StringToUTF16Ptr(windowTitle)
-
-	hwnd, err := findWindow(windowName)
+	hwnd, err := target.Window(windowTitle, targetFlags, findWindow)
 	if err != nil {
 		fmt.Println("Error finding window:", err)
-		return
+		os.Exit(1)
 	}
 
 	resizeWindow(hwnd, int32(width), int32(height))
@@ -3,8 +3,11 @@ package main
 import (
 	"flag"
 	"fmt"
+	"os"
 	"syscall"
 	"unsafe"
+
+	"gwctl/internal/target"
 )
 
 var (
@@ -28,15 +31,15 @@ func restoreWindow(hwnd syscall.Handle) {
 
 func main() {
 	var windowTitle string
+	var targetFlags target.Flags
 	flag.StringVar(&windowTitle, "title", "", "Window title to find and restore")
+	targetFlags.Register()
 	flag.Parse()
 
-	windowName := syscall.StringToUTF16Ptr(windowTitle)
-
-	hwnd, err := findWindow(windowName)
+	hwnd, err := target.Window(windowTitle, targetFlags, findWindow)
 	if err != nil {
 		fmt.Println("Error finding window:", err)
-		return
+		os.Exit(1)
 	}
 
 	restoreWindow(hwnd)
@@ -0,0 +1,321 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"syscall"
+	"time"
+	"unsafe"
+
+	"gwctl/internal/rules"
+)
+
+var (
+	modUser32                         = syscall.NewLazyDLL("user32.dll")
+	procSetWinEventHook               = modUser32.NewProc("SetWinEventHook")
+	procUnhookWinEvent                = modUser32.NewProc("UnhookWinEvent")
+	procGetMessage                    = modUser32.NewProc("GetMessageW")
+	procGetAncestor                   = modUser32.NewProc("GetAncestor")
+	procGetWindowText                 = modUser32.NewProc("GetWindowTextW")
+	procGetClassName                  = modUser32.NewProc("GetClassNameW")
+	procGetWindowThreadProcessId      = modUser32.NewProc("GetWindowThreadProcessId")
+	procGetWindowRect                 = modUser32.NewProc("GetWindowRect")
+	procSetWindowPos                  = modUser32.NewProc("SetWindowPos")
+	procShowWindow                    = modUser32.NewProc("ShowWindow")
+	procIsZoomed                      = modUser32.NewProc("IsZoomed")
+	procEnumDisplayMonitors           = modUser32.NewProc("EnumDisplayMonitors")
+	procMonitorFromWindow             = modUser32.NewProc("MonitorFromWindow")
+	procGetMonitorInfo                = modUser32.NewProc("GetMonitorInfoW")
+	modKernel32                       = syscall.NewLazyDLL("kernel32.dll")
+	procQueryFullProcessImageName     = modKernel32.NewProc("QueryFullProcessImageNameW")
+	EVENT_OBJECT_CREATE               = 0x8000
+	EVENT_OBJECT_DESTROY              = 0x8001
+	EVENT_OBJECT_SHOW                 = 0x8002
+	WINEVENT_OUTOFCONTEXT             = 0x0000
+	WINEVENT_SKIPOWNPROCESS           = 0x0002
+	OBJID_WINDOW                      = 0
+	CHILDID_SELF                      = 0
+	GA_ROOT                           = 2
+	SW_RESTORE                        = 9
+	HWND_TOPMOST                      = -1
+	SWP_NOSIZE                        = 0x0001
+	SWP_NOMOVE                        = 0x0002
+	SWP_NOZORDER                      = 0x0004
+	SWP_NOACTIVATE                    = 0x0010
+	MONITOR_DEFAULTTONEAREST          = 2
+	PROCESS_QUERY_LIMITED_INFORMATION = 0x1000
+)
+
+type rect struct {
+	left, top, right, bottom int32
+}
+
+type monitorInfo struct {
+	size    uint32
+	monitor rect
+	work    rect
+	flags   uint32
+}
+
+type msg struct {
+	hwnd    uintptr
+	message uint32
+	wParam  uintptr
+	lParam  uintptr
+	time    uint32
+	pt      struct{ x, y int32 }
+}
+
+func getWindowText(hwnd syscall.Handle) string {
+	buf := make([]uint16, 512)
+	procGetWindowText.Call(uintptr(hwnd), uintptr(unsafe.Pointer(&buf[0])), uintptr(len(buf)))
+	return syscall.UTF16ToString(buf)
+}
+
+func getClassName(hwnd syscall.Handle) string {
+	buf := make([]uint16, 256)
+	procGetClassName.Call(uintptr(hwnd), uintptr(unsafe.Pointer(&buf[0])), uintptr(len(buf)))
+	return syscall.UTF16ToString(buf)
+}
+
+// getProcessName returns the executable name of the window's process,
+// without the .exe extension.
+func getProcessName(hwnd syscall.Handle) string {
+	var pid uint32
+	procGetWindowThreadProcessId.Call(uintptr(hwnd), uintptr(unsafe.Pointer(&pid)))
+	if pid == 0 {
+		return ""
+	}
+	process, err := syscall.OpenProcess(uint32(PROCESS_QUERY_LIMITED_INFORMATION), false, pid)
+	if err != nil {
+		return ""
+	}
+	defer syscall.CloseHandle(process)
+
+	buf := make([]uint16, syscall.MAX_PATH)
+	size := uint32(len(buf))
+	ret, _, _ := procQueryFullProcessImageName.Call(uintptr(process), 0,
+		uintptr(unsafe.Pointer(&buf[0])), uintptr(unsafe.Pointer(&size)))
+	if ret == 0 {
+		return ""
+	}
+	name := filepath.Base(syscall.UTF16ToString(buf[:size]))
+	return strings.TrimSuffix(name, filepath.Ext(name))
+}
+
+// listMonitors returns the work areas of the monitors, in the order the
+// system enumerates them.
+func listMonitors() []rules.Rect {
+	var monitors []rules.Rect
+	callback := syscall.NewCallback(func(monitor, hdc uintptr, clip *rect, data uintptr) uintptr {
+		info := monitorInfo{size: uint32(unsafe.Sizeof(monitorInfo{}))}
+		if ret, _, _ := procGetMonitorInfo.Call(monitor, uintptr(unsafe.Pointer(&info))); ret != 0 {
+			monitors = append(monitors, workArea(info))
+		}
+		return 1
+	})
+	procEnumDisplayMonitors.Call(0, 0, callback, 0)
+	return monitors
+}
+
+func workArea(info monitorInfo) rules.Rect {
+	return rules.Rect{
+		X:      int(info.work.left),
+		Y:      int(info.work.top),
+		Width:  int(info.work.right - info.work.left),
+		Height: int(info.work.bottom - info.work.top),
+	}
+}
+
+// windowMonitor returns the work area of the monitor the window is on.
+func windowMonitor(hwnd syscall.Handle) (rules.Rect, error) {
+	monitor, _, _ := procMonitorFromWindow.Call(uintptr(hwnd), uintptr(MONITOR_DEFAULTTONEAREST))
+	info := monitorInfo{size: uint32(unsafe.Sizeof(monitorInfo{}))}
+	ret, _, err := procGetMonitorInfo.Call(monitor, uintptr(unsafe.Pointer(&info)))
+	if ret == 0 {
+		return rules.Rect{}, fmt.Errorf("failed to get monitor info: %v", err)
+	}
+	return workArea(info), nil
+}
+
+// targetGeometry works out where the rule puts the window.
+func targetGeometry(hwnd syscall.Handle, r rules.Rule) (rules.Rect, error) {
+	var current rect
+	ret, _, err := procGetWindowRect.Call(uintptr(hwnd), uintptr(unsafe.Pointer(&current)))
+	if ret == 0 {
+		return rules.Rect{}, fmt.Errorf("failed to get window rect: %v", err)
+	}
+
+	var mon rules.Rect
+	if r.Monitor > 0 {
+		monitors := listMonitors()
+		if r.Monitor > len(monitors) {
+			return rules.Rect{}, fmt.Errorf("there is no monitor %d", r.Monitor)
+		}
+		mon = monitors[r.Monitor-1]
+	} else {
+		mon, err = windowMonitor(hwnd)
+		if err != nil {
+			return rules.Rect{}, err
+		}
+	}
+
+	size := rules.Rect{
+		Width:  int(current.right - current.left),
+		Height: int(current.bottom - current.top),
+	}
+	return r.Geometry(size, mon), nil
+}
+
+// applyRule performs the rule's actions on the window, or only logs them
+// in a dry run.
+func applyRule(hwnd syscall.Handle, r rules.Rule, dryRun bool) {
+	log := slog.With("rule", r.Label(), "window", fmt.Sprintf("0x%x", uintptr(hwnd)), "dry_run", dryRun)
+
+	if r.Desktop != nil {
+		// IVirtualDesktopManager only moves windows of the calling process.
+		log.Warn("Moving windows of other programs to a desktop is not supported on Windows", "desktop", *r.Desktop)
+	}
+
+	if r.HasGeometry() {
+		geom, err := targetGeometry(hwnd, r)
+		if err != nil {
+			log.Error("Error placing window", "error", err)
+		} else {
+			log.Info("Placing window", "x", geom.X, "y", geom.Y, "width", geom.Width, "height", geom.Height)
+			if !dryRun {
+				// A maximized window would ignore the new geometry.
+				if zoomed, _, _ := procIsZoomed.Call(uintptr(hwnd)); zoomed != 0 {
+					procShowWindow.Call(uintptr(hwnd), uintptr(SW_RESTORE))
+				}
+				ret, _, err := procSetWindowPos.Call(
+					uintptr(hwnd),
+					0,
+					uintptr(geom.X),
+					uintptr(geom.Y),
+					uintptr(geom.Width),
+					uintptr(geom.Height),
+					uintptr(SWP_NOZORDER|SWP_NOACTIVATE),
+				)
+				if ret == 0 {
+					log.Error("Error placing window", "error", err)
+				}
+			}
+		}
+	}
+
+	if r.Above {
+		log.Info("Keeping window above others")
+		if !dryRun {
+			ret, _, err := procSetWindowPos.Call(uintptr(hwnd), uintptr(HWND_TOPMOST), 0, 0, 0, 0,
+				uintptr(SWP_NOMOVE|SWP_NOSIZE|SWP_NOACTIVATE))
+			if ret == 0 {
+				log.Error("Error keeping window above others", "error", err)
+			}
+		}
+	}
+}
+
+// handleNewWindow applies the matching rules to a newly shown window.
+func handleNewWindow(hwnd syscall.Handle, ruleList []rules.Rule, dryRun bool) {
+	info := rules.Window{
+		Title:   getWindowText(hwnd),
+		Class:   []string{getClassName(hwnd)},
+		Process: getProcessName(hwnd),
+	}
+	slog.Debug("New window", "window", fmt.Sprintf("0x%x", uintptr(hwnd)),
+		"title", info.Title, "class", info.Class[0], "process", info.Process)
+
+	for _, r := range ruleList {
+		if r.Matches(info) {
+			applyRule(hwnd, r, dryRun)
+		}
+	}
+}
+
+func main() {
+	var rulesPath string
+	var delay time.Duration
+	var dryRun, verbose bool
+	flag.StringVar(&rulesPath, "config", rules.DefaultPath(), "Rules file (JSON)")
+	flag.DurationVar(&delay, "delay", 200*time.Millisecond, "How long to wait after a window appears before applying rules")
+	flag.BoolVar(&dryRun, "dry-run", false, "Only log what the rules would do")
+	flag.BoolVar(&verbose, "verbose", false, "Also log every new window")
+	flag.Parse()
+
+	level := slog.LevelInfo
+	if verbose {
+		level = slog.LevelDebug
+	}
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: level})))
+
+	ruleList, err := rules.Load(rulesPath)
+	if err != nil {
+		fmt.Println("Error loading rules:", err)
+		os.Exit(1)
+	}
+
+	// Out-of-context events are delivered to the thread that set the hook,
+	// while it pumps messages.
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	// Rules apply when a window created after startup is first shown, so
+	// windows that are merely shown again are left alone.
+	created := make(map[syscall.Handle]bool)
+	callback := syscall.NewCallback(func(hook, event, hwnd, idObject, idChild, thread, eventTime uintptr) uintptr {
+		if int32(idObject) != int32(OBJID_WINDOW) || int32(idChild) != int32(CHILDID_SELF) || hwnd == 0 {
+			return 0
+		}
+		window := syscall.Handle(hwnd)
+		switch event {
+		case uintptr(EVENT_OBJECT_CREATE):
+			if root, _, _ := procGetAncestor.Call(hwnd, uintptr(GA_ROOT)); root == hwnd {
+				created[window] = true
+			}
+		case uintptr(EVENT_OBJECT_SHOW):
+			if created[window] {
+				delete(created, window)
+				// Give the program time to restore its own placement first,
+				// so the rule's placement is not overridden. Waiting here
+				// would hold up every later event.
+				time.AfterFunc(delay, func() {
+					handleNewWindow(window, ruleList, dryRun)
+				})
+			}
+		case uintptr(EVENT_OBJECT_DESTROY):
+			delete(created, window)
+		}
+		return 0
+	})
+
+	hook, _, err := procSetWinEventHook.Call(
+		uintptr(EVENT_OBJECT_CREATE),
+		uintptr(EVENT_OBJECT_SHOW),
+		0,
+		callback,
+		0,
+		0,
+		uintptr(WINEVENT_OUTOFCONTEXT|WINEVENT_SKIPOWNPROCESS),
+	)
+	if hook == 0 {
+		fmt.Println("Error watching for new windows:", err)
+		os.Exit(1)
+	}
+	defer procUnhookWinEvent.Call(hook)
+
+	slog.Info("Watching for new windows", "rules", len(ruleList), "config", rulesPath, "dry_run", dryRun)
+
+	var m msg
+	for {
+		ret, _, _ := procGetMessage.Call(uintptr(unsafe.Pointer(&m)), 0, 0, 0)
+		if int32(ret) <= 0 {
+			break
+		}
+	}
+}
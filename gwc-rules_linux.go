@@ -0,0 +1,324 @@
+//go:build linux
+// +build linux
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/BurntSushi/xgb"
+	"github.com/BurntSushi/xgb/xinerama"
+	"github.com/BurntSushi/xgb/xproto"
+	"gwctl/internal/rules"
+	"gwctl/internal/target"
+	"gwctl/internal/x11"
+)
+
+// getProcessName returns the executable name of the window's process, if
+// it runs on this machine.
+func getProcessName(conn *xgb.Conn, window xproto.Window) string {
+	pid, ok := x11.Cardinal(conn, window, "_NET_WM_PID")
+	if !ok {
+		return ""
+	}
+	if exe, err := os.Readlink(fmt.Sprintf("/proc/%d/exe", pid)); err == nil {
+		return filepath.Base(exe)
+	}
+	if comm, err := os.ReadFile(fmt.Sprintf("/proc/%d/comm", pid)); err == nil {
+		return strings.TrimSpace(string(comm))
+	}
+	return ""
+}
+
+func setWindowState(conn *xgb.Conn, window xproto.Window, action uint32, name string) error {
+	atom, err := x11.InternAtom(conn, name)
+	if err != nil {
+		return err
+	}
+	return x11.SendClientMessage(conn, window, "_NET_WM_STATE", action, uint32(atom), 0, x11.SourcePager)
+}
+
+// listMonitors returns the Xinerama monitors, or the whole screen if the
+// extension is not available.
+func listMonitors(conn *xgb.Conn) []rules.Rect {
+	screen := xproto.Setup(conn).DefaultScreen(conn)
+	full := []rules.Rect{{X: 0, Y: 0, Width: int(screen.WidthInPixels), Height: int(screen.HeightInPixels)}}
+
+	if err := xinerama.Init(conn); err != nil {
+		return full
+	}
+	screens, err := xinerama.QueryScreens(conn).Reply()
+	if err != nil || len(screens.ScreenInfo) == 0 {
+		return full
+	}
+
+	var monitors []rules.Rect
+	for _, info := range screens.ScreenInfo {
+		monitors = append(monitors, rules.Rect{X: int(info.XOrg), Y: int(info.YOrg), Width: int(info.Width), Height: int(info.Height)})
+	}
+	return monitors
+}
+
+// workArea returns _NET_WORKAREA for the current desktop: the screen minus
+// panels and docks. ok is false if the window manager does not set it.
+func workArea(conn *xgb.Conn) (rules.Rect, bool) {
+	atom, err := x11.InternAtom(conn, "_NET_WORKAREA")
+	if err != nil {
+		return rules.Rect{}, false
+	}
+	root := xproto.Setup(conn).DefaultScreen(conn).Root
+	reply, err := xproto.GetProperty(conn, false, root,
+		atom, xproto.AtomCardinal, 0, (1<<32)-1).Reply()
+	if err != nil || len(reply.Value) < 16 {
+		return rules.Rect{}, false
+	}
+
+	desktop, _ := x11.Cardinal(conn, root, "_NET_CURRENT_DESKTOP")
+	offset := int(desktop) * 16
+	if offset+16 > len(reply.Value) {
+		offset = 0
+	}
+	value := reply.Value[offset:]
+	return rules.Rect{
+		X:      int(int32(xgb.Get32(value))),
+		Y:      int(int32(xgb.Get32(value[4:]))),
+		Width:  int(xgb.Get32(value[8:])),
+		Height: int(xgb.Get32(value[12:])),
+	}, true
+}
+
+// windowGeometry returns the window's position on the root window and size.
+func windowGeometry(conn *xgb.Conn, window xproto.Window) (rules.Rect, error) {
+	geom, err := xproto.GetGeometry(conn, xproto.Drawable(window)).Reply()
+	if err != nil {
+		return rules.Rect{}, err
+	}
+	root := xproto.Setup(conn).DefaultScreen(conn).Root
+	pos, err := xproto.TranslateCoordinates(conn, window, root, 0, 0).Reply()
+	if err != nil {
+		return rules.Rect{}, err
+	}
+	return rules.Rect{X: int(pos.DstX), Y: int(pos.DstY), Width: int(geom.Width), Height: int(geom.Height)}, nil
+}
+
+// targetGeometry works out where the rule puts the window. Like on
+// Windows, percentages and centering are relative to the monitor's work
+// area: the part of _NET_WORKAREA on the monitor.
+func targetGeometry(conn *xgb.Conn, window xproto.Window, r rules.Rule) (rules.Rect, error) {
+	current, err := windowGeometry(conn, window)
+	if err != nil {
+		return rules.Rect{}, err
+	}
+
+	monitors := listMonitors(conn)
+	var mon rules.Rect
+	if r.Monitor > 0 {
+		if r.Monitor > len(monitors) {
+			return rules.Rect{}, fmt.Errorf("there is no monitor %d", r.Monitor)
+		}
+		mon = monitors[r.Monitor-1]
+	} else {
+		// The monitor holding the window's center, or the first one.
+		mon = monitors[0]
+		cx, cy := current.X+current.Width/2, current.Y+current.Height/2
+		for _, m := range monitors {
+			if cx >= m.X && cx < m.X+m.Width && cy >= m.Y && cy < m.Y+m.Height {
+				mon = m
+				break
+			}
+		}
+	}
+
+	if area, ok := workArea(conn); ok {
+		if work, ok := mon.Intersect(area); ok {
+			mon = work
+		}
+	}
+	return r.Geometry(current, mon), nil
+}
+
+// applyRule performs the rule's actions on the window, or only logs them
+// in a dry run.
+func applyRule(conn *xgb.Conn, window xproto.Window, r rules.Rule, dryRun bool) {
+	log := slog.With("rule", r.Label(), "window", fmt.Sprintf("0x%x", uint32(window)), "dry_run", dryRun)
+
+	if r.Desktop != nil {
+		desktop := uint32(*r.Desktop)
+		log.Info("Moving window to desktop", "desktop", *r.Desktop)
+		if !dryRun {
+			if err := x11.SendClientMessage(conn, window, "_NET_WM_DESKTOP", desktop, x11.SourcePager); err != nil {
+				log.Error("Error moving window to desktop", "error", err)
+			}
+		}
+	}
+
+	if r.HasGeometry() {
+		geom, err := targetGeometry(conn, window, r)
+		if err != nil {
+			log.Error("Error placing window", "error", err)
+		} else {
+			log.Info("Placing window", "x", geom.X, "y", geom.Y, "width", geom.Width, "height", geom.Height)
+			if !dryRun {
+				// A maximized window would ignore the new geometry.
+				setWindowState(conn, window, x11.StateRemove, "_NET_WM_STATE_MAXIMIZED_VERT")
+				setWindowState(conn, window, x11.StateRemove, "_NET_WM_STATE_MAXIMIZED_HORZ")
+				err := xproto.ConfigureWindowChecked(conn, window,
+					xproto.ConfigWindowX|xproto.ConfigWindowY|xproto.ConfigWindowWidth|xproto.ConfigWindowHeight,
+					[]uint32{uint32(int32(geom.X)), uint32(int32(geom.Y)), uint32(geom.Width), uint32(geom.Height)}).Check()
+				if err != nil {
+					log.Error("Error placing window", "error", err)
+				}
+			}
+		}
+	}
+
+	if r.Above {
+		log.Info("Keeping window above others")
+		if !dryRun {
+			if err := setWindowState(conn, window, x11.StateAdd, "_NET_WM_STATE_ABOVE"); err != nil {
+				log.Error("Error keeping window above others", "error", err)
+			}
+		}
+	}
+}
+
+// handleNewWindow applies the matching rules to the client inside a newly
+// mapped top-level window.
+func handleNewWindow(conn *xgb.Conn, topLevel xproto.Window, ruleList []rules.Rule, dryRun bool) {
+	window := target.ClientWindow(conn, topLevel)
+	if window == 0 {
+		return
+	}
+	info := rules.Window{
+		Title:   x11.WindowName(conn, window),
+		Class:   x11.WindowClass(conn, window),
+		Process: getProcessName(conn, window),
+	}
+	slog.Debug("New window", "window", fmt.Sprintf("0x%x", uint32(window)),
+		"title", info.Title, "class", strings.Join(info.Class, ","), "process", info.Process)
+
+	for _, r := range ruleList {
+		if r.Matches(info) {
+			applyRule(conn, window, r, dryRun)
+		}
+	}
+}
+
+func main() {
+	var rulesPath string
+	var delay time.Duration
+	var dryRun, verbose bool
+	flag.StringVar(&rulesPath, "config", rules.DefaultPath(), "Rules file (JSON)")
+	flag.DurationVar(&delay, "delay", 200*time.Millisecond, "How long to wait after a window appears before applying rules")
+	flag.BoolVar(&dryRun, "dry-run", false, "Only log what the rules would do")
+	flag.BoolVar(&verbose, "verbose", false, "Also log every new window")
+	flag.Parse()
+
+	level := slog.LevelInfo
+	if verbose {
+		level = slog.LevelDebug
+	}
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: level})))
+
+	ruleList, err := rules.Load(rulesPath)
+	if err != nil {
+		fmt.Println("Error loading rules:", err)
+		os.Exit(1)
+	}
+
+	conn, err := xgb.NewConn()
+	if err != nil {
+		fmt.Println("Error opening display:", err)
+		os.Exit(1)
+	}
+	defer conn.Close()
+
+	root := xproto.Setup(conn).DefaultScreen(conn).Root
+	err = xproto.ChangeWindowAttributesChecked(conn, root, xproto.CwEventMask,
+		[]uint32{xproto.EventMaskSubstructureNotify}).Check()
+	if err != nil {
+		fmt.Println("Error watching for new windows:", err)
+		os.Exit(1)
+	}
+
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
+
+	// Events are read on their own goroutine so the loop below can also
+	// wait for signals and delayed windows. Everything that uses the
+	// connection stays on the loop, and the connection is only closed once
+	// it has returned.
+	events := make(chan xgb.Event)
+	go func() {
+		defer close(events)
+		for {
+			ev, xerr := conn.WaitForEvent()
+			if ev == nil && xerr == nil {
+				return
+			}
+			if xerr != nil {
+				slog.Debug("X error", "error", xerr)
+				continue
+			}
+			events <- ev
+		}
+	}()
+
+	slog.Info("Watching for new windows", "rules", len(ruleList), "config", rulesPath, "dry_run", dryRun)
+
+	// Rules apply when a window created after startup is first mapped, so
+	// windows that are merely shown again (e.g. by gwc-tray) are left alone.
+	created := make(map[xproto.Window]bool)
+	due := make(chan xproto.Window)
+	for {
+		var ev xgb.Event
+		select {
+		case sig := <-sigs:
+			slog.Info("Signal received, exiting", "signal", sig.String())
+			return
+		case window := <-due:
+			handleNewWindow(conn, window, ruleList, dryRun)
+			continue
+		case e, ok := <-events:
+			if !ok {
+				// xgb has closed the connection itself; the deferred Close
+				// must not close it again.
+				slog.Error("Connection to the X server lost, exiting")
+				os.Exit(1)
+			}
+			ev = e
+		}
+
+		switch e := ev.(type) {
+		case xproto.CreateNotifyEvent:
+			if e.Parent == root && !e.OverrideRedirect {
+				created[e.Window] = true
+			}
+		case xproto.MapNotifyEvent:
+			if created[e.Window] {
+				delete(created, e.Window)
+				// Give the window manager time to set WM_STATE and place
+				// the window, so the rule's placement is not overridden.
+				window := e.Window
+				time.AfterFunc(delay, func() {
+					due <- window
+				})
+			}
+		case xproto.ReparentNotifyEvent:
+			// The window manager framed it; the frame's own map counts.
+			if e.Parent != root {
+				delete(created, e.Window)
+			}
+		case xproto.DestroyNotifyEvent:
+			delete(created, e.Window)
+		}
+	}
+}
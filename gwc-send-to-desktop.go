@@ -0,0 +1,205 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"runtime"
+	"strconv"
+	"syscall"
+	"unsafe"
+
+	"gwctl/internal/target"
+)
+
+var (
+	modUser32                = syscall.NewLazyDLL("user32.dll")
+	procFindWindow           = modUser32.NewProc("FindWindowW")
+	modOle32                 = syscall.NewLazyDLL("ole32.dll")
+	procCoInitializeEx       = modOle32.NewProc("CoInitializeEx")
+	procCoUninitialize       = modOle32.NewProc("CoUninitialize")
+	procCoCreateInstance     = modOle32.NewProc("CoCreateInstance")
+	COINIT_APARTMENTTHREADED = 0x2
+	CLSCTX_ALL               = 0x17
+	E_ACCESSDENIED           = 0x80070005
+	virtualDesktopsKey       = `Software\Microsoft\Windows\CurrentVersion\Explorer\VirtualDesktops`
+)
+
+type guid struct {
+	data1 uint32
+	data2 uint16
+	data3 uint16
+	data4 [8]byte
+}
+
+var (
+	clsidVirtualDesktopManager = guid{0xaa509086, 0x5ca9, 0x4c25, [8]byte{0x8f, 0x95, 0x58, 0x9d, 0x3c, 0x07, 0xb4, 0x8a}}
+	iidIVirtualDesktopManager  = guid{0xa5cd92ff, 0x29be, 0x454c, [8]byte{0x8d, 0x04, 0xd8, 0x28, 0x79, 0xfb, 0x3f, 0x1b}}
+)
+
+// IVirtualDesktopManager vtable slots, after the three IUnknown methods.
+const (
+	vtblRelease             = 2
+	vtblGetWindowDesktopId  = 4
+	vtblMoveWindowToDesktop = 5
+)
+
+type virtualDesktopManager struct {
+	vtbl *[6]uintptr
+}
+
+func findWindow(windowName *uint16) (syscall.Handle, error) {
+	ret, _, err := procFindWindow.Call(uintptr(unsafe.Pointer(nil)), uintptr(unsafe.Pointer(windowName)))
+	if ret == 0 {
+		return 0, err
+	}
+	return syscall.Handle(ret), nil
+}
+
+// readRegistryValue reads a value below HKEY_CURRENT_USER.
+func readRegistryValue(path, name string) ([]byte, error) {
+	var key syscall.Handle
+	err := syscall.RegOpenKeyEx(syscall.HKEY_CURRENT_USER, syscall.StringToUTF16Ptr(path), 0, syscall.KEY_READ, &key)
+	if err != nil {
+		return nil, err
+	}
+	defer syscall.RegCloseKey(key)
+
+	var size uint32
+	namePtr := syscall.StringToUTF16Ptr(name)
+	err = syscall.RegQueryValueEx(key, namePtr, nil, nil, nil, &size)
+	if err != nil {
+		return nil, err
+	}
+	if size == 0 {
+		return nil, nil
+	}
+	buf := make([]byte, size)
+	err = syscall.RegQueryValueEx(key, namePtr, nil, nil, &buf[0], &size)
+	if err != nil {
+		return nil, err
+	}
+	return buf[:size], nil
+}
+
+// listDesktops returns the IDs of the virtual desktops in order, as kept
+// by Explorer. The registry stores them in GUID memory layout.
+func listDesktops() ([]guid, error) {
+	ids, err := readRegistryValue(virtualDesktopsKey, "VirtualDesktopIDs")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read virtual desktops: %v", err)
+	}
+
+	var desktops []guid
+	for i := 0; i+16 <= len(ids); i += 16 {
+		desktops = append(desktops, *(*guid)(unsafe.Pointer(&ids[i])))
+	}
+	return desktops, nil
+}
+
+func newVirtualDesktopManager() (*virtualDesktopManager, error) {
+	var manager *virtualDesktopManager
+	hr, _, _ := procCoCreateInstance.Call(
+		uintptr(unsafe.Pointer(&clsidVirtualDesktopManager)),
+		0,
+		uintptr(CLSCTX_ALL),
+		uintptr(unsafe.Pointer(&iidIVirtualDesktopManager)),
+		uintptr(unsafe.Pointer(&manager)),
+	)
+	if int32(hr) < 0 {
+		return nil, fmt.Errorf("failed to create IVirtualDesktopManager: HRESULT 0x%08x", uint32(hr))
+	}
+	return manager, nil
+}
+
+func (m *virtualDesktopManager) release() {
+	syscall.SyscallN(m.vtbl[vtblRelease], uintptr(unsafe.Pointer(m)))
+}
+
+func (m *virtualDesktopManager) windowDesktop(hwnd syscall.Handle) (guid, error) {
+	var id guid
+	hr, _, _ := syscall.SyscallN(m.vtbl[vtblGetWindowDesktopId],
+		uintptr(unsafe.Pointer(m)), uintptr(hwnd), uintptr(unsafe.Pointer(&id)))
+	if int32(hr) < 0 {
+		return id, fmt.Errorf("failed to get window desktop: HRESULT 0x%08x", uint32(hr))
+	}
+	return id, nil
+}
+
+func (m *virtualDesktopManager) moveWindow(hwnd syscall.Handle, id guid) error {
+	hr, _, _ := syscall.SyscallN(m.vtbl[vtblMoveWindowToDesktop],
+		uintptr(unsafe.Pointer(m)), uintptr(hwnd), uintptr(unsafe.Pointer(&id)))
+	if uint32(hr) == uint32(E_ACCESSDENIED) {
+		return fmt.Errorf("Windows only allows moving windows of the calling process")
+	}
+	if int32(hr) < 0 {
+		return fmt.Errorf("failed to move window: HRESULT 0x%08x", uint32(hr))
+	}
+	return nil
+}
+
+func main() {
+	var windowTitle string
+	var targetFlags target.Flags
+	var sticky bool
+	flag.StringVar(&windowTitle, "title", "", "Window title to find and move to another desktop")
+	targetFlags.Register()
+	flag.BoolVar(&sticky, "sticky", false, "Show the window on all desktops instead of moving it to one")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "Usage: gwc-send-to-desktop -title TITLE N | -sticky")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if sticky == (flag.NArg() == 1) || flag.NArg() > 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if sticky {
+		// Pinning is only available through undocumented shell interfaces.
+		fmt.Println("Showing a window on all desktops is not supported on Windows.")
+		os.Exit(1)
+	}
+
+	desktops, err := listDesktops()
+	if err != nil {
+		fmt.Println("Error listing desktops:", err)
+		os.Exit(1)
+	}
+	n, err := strconv.Atoi(flag.Arg(0))
+	if err != nil || n < 0 || n >= len(desktops) {
+		fmt.Printf("Invalid desktop '%s', expected 0 to %d.\n", flag.Arg(0), len(desktops)-1)
+		os.Exit(1)
+	}
+
+	hwnd, err := target.Window(windowTitle, targetFlags, findWindow)
+	if err != nil {
+		fmt.Println("Error finding window:", err)
+		os.Exit(1)
+	}
+
+	// COM is initialized per thread.
+	runtime.LockOSThread()
+	procCoInitializeEx.Call(0, uintptr(COINIT_APARTMENTTHREADED))
+	defer procCoUninitialize.Call()
+
+	manager, err := newVirtualDesktopManager()
+	if err != nil {
+		fmt.Println("Error moving window:", err)
+		os.Exit(1)
+	}
+	defer manager.release()
+
+	err = manager.moveWindow(hwnd, desktops[n])
+	if err != nil {
+		fmt.Println("Error moving window:", err)
+		os.Exit(1)
+	}
+
+	if id, err := manager.windowDesktop(hwnd); err != nil || id != desktops[n] {
+		fmt.Println("Windows did not move the window.")
+		os.Exit(1)
+	}
+	fmt.Printf("Window moved to desktop %d.\n", n)
+}
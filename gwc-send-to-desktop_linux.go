@@ -0,0 +1,104 @@
+//go:build linux
+// +build linux
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/BurntSushi/xgb"
+	"github.com/BurntSushi/xgb/xproto"
+	"gwctl/internal/target"
+	"gwctl/internal/x11"
+)
+
+// allDesktops is the _NET_WM_DESKTOP value of a window shown on every desktop.
+const allDesktops = 0xffffffff
+
+// waitForWindowDesktop polls _NET_WM_DESKTOP until it is want or the timeout
+// passes, and reports whether it got there.
+func waitForWindowDesktop(conn *xgb.Conn, window xproto.Window, want uint32, timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for {
+		desktop, ok := x11.Cardinal(conn, window, "_NET_WM_DESKTOP")
+		if ok && desktop == want {
+			return true
+		}
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func main() {
+	var windowTitle string
+	var targetFlags target.Flags
+	var sticky bool
+	var timeout time.Duration
+	flag.StringVar(&windowTitle, "title", "", "Window title to find and move to another desktop")
+	targetFlags.Register()
+	flag.BoolVar(&sticky, "sticky", false, "Show the window on all desktops instead of moving it to one")
+	flag.DurationVar(&timeout, "timeout", time.Second, "How long to wait for the window manager to apply the change")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "Usage: gwc-send-to-desktop -title TITLE N | -sticky")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if sticky == (flag.NArg() == 1) || flag.NArg() > 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	conn, err := xgb.NewConn()
+	if err != nil {
+		fmt.Println("Error opening display:", err)
+		os.Exit(1)
+	}
+	defer conn.Close()
+
+	root := xproto.Setup(conn).DefaultScreen(conn).Root
+
+	desktop := uint32(allDesktops)
+	if !sticky {
+		count, ok := x11.Cardinal(conn, root, "_NET_NUMBER_OF_DESKTOPS")
+		if !ok {
+			fmt.Println("The window manager does not support virtual desktops.")
+			os.Exit(1)
+		}
+		n, err := strconv.ParseUint(flag.Arg(0), 10, 32)
+		if err != nil || uint32(n) >= count {
+			fmt.Printf("Invalid desktop '%s', expected 0 to %d.\n", flag.Arg(0), count-1)
+			os.Exit(1)
+		}
+		desktop = uint32(n)
+	}
+
+	window, err := target.Window(conn, windowTitle, targetFlags, x11.FindWindow)
+	if err != nil {
+		fmt.Println("Error finding window:", err)
+		os.Exit(1)
+	}
+
+	err = x11.SendClientMessage(conn, window, "_NET_WM_DESKTOP", desktop, x11.SourcePager)
+	if err != nil {
+		fmt.Println("Error moving window:", err)
+		os.Exit(1)
+	}
+
+	if !waitForWindowDesktop(conn, window, desktop, timeout) {
+		fmt.Println("The window manager did not move the window.")
+		os.Exit(1)
+	}
+
+	if sticky {
+		fmt.Println("Window is shown on all desktops.")
+	} else {
+		fmt.Printf("Window moved to desktop %d.\n", desktop)
+	}
+}
@@ -0,0 +1,52 @@
+//go:build linux
+// +build linux
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/BurntSushi/xgb"
+	"gwctl/internal/altab"
+	"gwctl/internal/target"
+	"gwctl/internal/x11"
+)
+
+func main() {
+	var windowTitle string
+	var targetFlags target.Flags
+	var timeout time.Duration
+	flag.StringVar(&windowTitle, "title", "", "Window title to find and show in Alt+Tab, taskbar and pager")
+	targetFlags.Register()
+	flag.DurationVar(&timeout, "timeout", time.Second, "How long to wait for the window manager to apply the change")
+	flag.Parse()
+
+	conn, err := xgb.NewConn()
+	if err != nil {
+		fmt.Println("Error opening display:", err)
+		os.Exit(1)
+	}
+	defer conn.Close()
+
+	window, err := target.Window(conn, windowTitle, targetFlags, x11.FindWindow)
+	if err != nil {
+		fmt.Println("Error finding window:", err)
+		os.Exit(1)
+	}
+
+	hidden, err := altab.SetStatesHidden(altab.X11Window{Conn: conn, Window: window}, false, timeout)
+	if err != nil {
+		fmt.Println("Error showing window in Alt+Tab:", err)
+		os.Exit(1)
+	}
+
+	if hidden {
+		fmt.Println("Window manager did not apply the change, window is still hidden from Alt+Tab.")
+		os.Exit(1)
+	}
+
+	fmt.Println("Window shown in Alt+Tab.")
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"syscall"
+	"unsafe"
+
+	"gwctl/internal/target"
+)
+
+var (
+	modUser32        = syscall.NewLazyDLL("user32.dll")
+	procFindWindow   = modUser32.NewProc("FindWindowW")
+	procSetWindowPos = modUser32.NewProc("SetWindowPos")
+	procGetWindow    = modUser32.NewProc("GetWindow")
+	HWND_TOP         = 0
+	GW_HWNDPREV      = 3
+	SWP_NOSIZE       = 0x0001
+	SWP_NOMOVE       = 0x0002
+	SWP_NOACTIVATE   = 0x0010
+)
+
+func findWindow(windowName *uint16) (syscall.Handle, error) {
+	ret, _, err := procFindWindow.Call(uintptr(unsafe.Pointer(nil)), uintptr(unsafe.Pointer(windowName)))
+	if ret == 0 {
+		return 0, err
+	}
+	return syscall.Handle(ret), nil
+}
+
+// placeAfter puts hwnd directly below insertAfter in the z-order.
+func placeAfter(hwnd, insertAfter syscall.Handle) error {
+	ret, _, err := procSetWindowPos.Call(
+		uintptr(hwnd),
+		uintptr(insertAfter),
+		0,
+		0,
+		0,
+		0,
+		uintptr(SWP_NOMOVE|SWP_NOSIZE|SWP_NOACTIVATE),
+	)
+	if ret == 0 {
+		return fmt.Errorf("failed to set window position: %v", err)
+	}
+	return nil
+}
+
+// placeAbove puts hwnd directly above sibling. SetWindowPos can only place a
+// window after another one, so it goes after the window above sibling.
+func placeAbove(hwnd, sibling syscall.Handle) error {
+	prev, _, _ := procGetWindow.Call(uintptr(sibling), uintptr(GW_HWNDPREV))
+	if syscall.Handle(prev) == hwnd {
+		return nil
+	}
+	if prev == 0 {
+		return placeAfter(hwnd, syscall.Handle(HWND_TOP))
+	}
+	return placeAfter(hwnd, syscall.Handle(prev))
+}
+
+func main() {
+	var windowTitle, aboveTitle, belowTitle string
+	var targetFlags target.Flags
+	flag.StringVar(&windowTitle, "title", "", "Window title to find and restack")
+	targetFlags.Register()
+	flag.StringVar(&aboveTitle, "above", "", "Title of the window to place it directly above")
+	flag.StringVar(&belowTitle, "below", "", "Title of the window to place it directly below")
+	flag.Parse()
+
+	if (aboveTitle == "") == (belowTitle == "") {
+		fmt.Println("Please provide exactly one of the -above or -below flags.")
+		os.Exit(1)
+	}
+
+	hwnd, err := target.Window(windowTitle, targetFlags, findWindow)
+	if err != nil {
+		fmt.Println("Error finding window:", err)
+		os.Exit(1)
+	}
+
+	siblingTitle := aboveTitle + belowTitle
+	sibling, err := findWindow(syscall.StringToUTF16Ptr(siblingTitle))
+	if err != nil {
+		fmt.Println("Error finding window:", err)
+		os.Exit(1)
+	}
+
+	if aboveTitle != "" {
+		err = placeAbove(hwnd, sibling)
+	} else {
+		err = placeAfter(hwnd, sibling)
+	}
+	if err != nil {
+		fmt.Println("Error changing window z-order:", err)
+		os.Exit(1)
+	}
+
+	fmt.Println("Window restacked.")
+}
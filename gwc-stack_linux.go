@@ -0,0 +1,62 @@
+//go:build linux
+// +build linux
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/BurntSushi/xgb"
+	"github.com/BurntSushi/xgb/xproto"
+	"gwctl/internal/target"
+	"gwctl/internal/x11"
+)
+
+func main() {
+	var windowTitle, aboveTitle, belowTitle string
+	var targetFlags target.Flags
+	flag.StringVar(&windowTitle, "title", "", "Window title to find and restack")
+	targetFlags.Register()
+	flag.StringVar(&aboveTitle, "above", "", "Title of the window to place it directly above")
+	flag.StringVar(&belowTitle, "below", "", "Title of the window to place it directly below")
+	flag.Parse()
+
+	if (aboveTitle == "") == (belowTitle == "") {
+		fmt.Println("Please provide exactly one of the -above or -below flags.")
+		os.Exit(1)
+	}
+
+	conn, err := xgb.NewConn()
+	if err != nil {
+		fmt.Println("Error opening display:", err)
+		os.Exit(1)
+	}
+	defer conn.Close()
+
+	window, err := target.Window(conn, windowTitle, targetFlags, x11.FindWindow)
+	if err != nil {
+		fmt.Println("Error finding window:", err)
+		os.Exit(1)
+	}
+
+	sibling, err := x11.FindWindow(conn, aboveTitle+belowTitle)
+	if err != nil {
+		fmt.Println("Error finding window:", err)
+		os.Exit(1)
+	}
+
+	mode := byte(xproto.StackModeAbove)
+	if belowTitle != "" {
+		mode = xproto.StackModeBelow
+	}
+
+	err = x11.RestackWindow(conn, window, sibling, mode)
+	if err != nil {
+		fmt.Println("Error changing window z-order:", err)
+		os.Exit(1)
+	}
+
+	fmt.Println("Window restacked.")
+}
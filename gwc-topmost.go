@@ -0,0 +1,107 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"syscall"
+	"unsafe"
+
+	"gwctl/internal/target"
+)
+
+var (
+	modUser32         = syscall.NewLazyDLL("user32.dll")
+	procFindWindow    = modUser32.NewProc("FindWindowW")
+	procSetWindowPos  = modUser32.NewProc("SetWindowPos")
+	procGetWindowLong = modUser32.NewProc("GetWindowLongW")
+	GWL_EXSTYLE       = -20
+	WS_EX_TOPMOST     = 0x00000008
+	HWND_TOPMOST      = -1
+	HWND_NOTOPMOST    = -2
+	SWP_NOSIZE        = 0x0001
+	SWP_NOMOVE        = 0x0002
+	SWP_NOACTIVATE    = 0x0010
+)
+
+func findWindow(windowName *uint16) (syscall.Handle, error) {
+	ret, _, err := procFindWindow.Call(uintptr(unsafe.Pointer(nil)), uintptr(unsafe.Pointer(windowName)))
+	if ret == 0 {
+		return 0, err
+	}
+	return syscall.Handle(ret), nil
+}
+
+func isTopmost(hwnd syscall.Handle) bool {
+	style, _, _ := procGetWindowLong.Call(uintptr(hwnd), uintptr(GWL_EXSTYLE))
+	return style&uintptr(WS_EX_TOPMOST) != 0
+}
+
+func setTopmost(hwnd syscall.Handle, topmost bool) error {
+	insertAfter := HWND_NOTOPMOST
+	if topmost {
+		insertAfter = HWND_TOPMOST
+	}
+
+	ret, _, err := procSetWindowPos.Call(
+		uintptr(hwnd),
+		uintptr(insertAfter),
+		0,
+		0,
+		0,
+		0,
+		uintptr(SWP_NOMOVE|SWP_NOSIZE|SWP_NOACTIVATE),
+	)
+	if ret == 0 {
+		return fmt.Errorf("failed to set window position: %v", err)
+	}
+	return nil
+}
+
+func main() {
+	var windowTitle string
+	var targetFlags target.Flags
+	flag.StringVar(&windowTitle, "title", "", "Window title to find and keep on top")
+	targetFlags.Register()
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "Usage: gwc-topmost -title TITLE [on|off|toggle]")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	action := "on"
+	if flag.NArg() > 0 {
+		action = flag.Arg(0)
+	}
+
+	hwnd, err := target.Window(windowTitle, targetFlags, findWindow)
+	if err != nil {
+		fmt.Println("Error finding window:", err)
+		os.Exit(1)
+	}
+
+	var topmost bool
+	switch action {
+	case "on":
+		topmost = true
+	case "off":
+		topmost = false
+	case "toggle":
+		topmost = !isTopmost(hwnd)
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	err = setTopmost(hwnd, topmost)
+	if err != nil {
+		fmt.Println("Error changing window z-order:", err)
+		os.Exit(1)
+	}
+
+	if isTopmost(hwnd) {
+		fmt.Println("Window is always on top.")
+	} else {
+		fmt.Println("Window is no longer always on top.")
+	}
+}
@@ -0,0 +1,106 @@
+//go:build linux
+// +build linux
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"time"
+
+	"gwctl/internal/control"
+)
+
+type rpcRequest struct {
+	JSONRPC string      `json:"jsonrpc"`
+	ID      int         `json:"id"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      int             `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+func callTray(socketPath string, timeout time.Duration, method string, params interface{}) (json.RawMessage, error) {
+	conn, err := net.DialTimeout("unix", socketPath, timeout)
+	if err != nil {
+		return nil, fmt.Errorf("cannot connect to tray at %s: %v", socketPath, err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(timeout))
+
+	req := rpcRequest{JSONRPC: "2.0", ID: 1, Method: method, Params: params}
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return nil, err
+	}
+
+	line, err := bufio.NewReader(conn).ReadBytes('\n')
+	if err != nil {
+		return nil, fmt.Errorf("no response from tray: %v", err)
+	}
+
+	var resp rpcResponse
+	if err := json.Unmarshal(line, &resp); err != nil {
+		return nil, fmt.Errorf("invalid response from tray: %v", err)
+	}
+	if resp.Error != nil {
+		return nil, fmt.Errorf("%s (code %d)", resp.Error.Message, resp.Error.Code)
+	}
+	return resp.Result, nil
+}
+
+func main() {
+	var socketPath, winTitle, winClass, winID, keyCombo string
+	var timeout time.Duration
+	flag.StringVar(&socketPath, "socket", "", "Control socket path of the running tray")
+	flag.StringVar(&winTitle, "title", "", "Window title the tray was started with")
+	flag.StringVar(&winClass, "class", "", "Window class the tray was started with")
+	flag.StringVar(&winID, "id", "", "Window ID the tray was started with")
+	flag.StringVar(&keyCombo, "key", "", "New keyboard shortcut for 'rebind' (empty to remove it)")
+	flag.DurationVar(&timeout, "timeout", 15*time.Second, "How long to wait for the tray to answer")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] toggle|show|hide|status|rebind|reload|quit\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	method := flag.Arg(0)
+
+	if socketPath == "" {
+		if winTitle == "" && winClass == "" && winID == "" {
+			fmt.Println("Error: Either -socket, -title, -class or -id must be specified")
+			os.Exit(2)
+		}
+		socketPath = control.SocketPath(winTitle, winClass, winID)
+	}
+
+	var params interface{}
+	if method == "rebind" {
+		params = map[string]string{"key": keyCombo}
+	}
+
+	result, err := callTray(socketPath, timeout, method, params)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(1)
+	}
+
+	fmt.Println(string(result))
+}
@@ -4,65 +4,243 @@
 package main
 
 import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
-	"log"
+	"io"
+	"log/slog"
+	"net"
 	"os"
+	"os/exec"
 	"os/signal"
+	"runtime"
 	"strconv"
 	"strings"
 	"sync"
 	"syscall"
+	"time"
 
 	"github.com/BurntSushi/xgb"
+	"github.com/BurntSushi/xgb/xinerama"
 	"github.com/BurntSushi/xgb/xproto"
 	"github.com/getlantern/systray"
 	"github.com/getlantern/systray/example/icon"
+	"gwctl/internal/control"
+	"gwctl/internal/keyboard"
+	"gwctl/internal/target"
 )
 
 type AppState struct {
-	conn       *xgb.Conn
-	winTitle   string
-	winID      string
-	targetWin  xproto.Window
-	isVisible  bool
-	keyCombo   string
-	keyMods    uint16
-	keyCode    xproto.Keycode
-	root       xproto.Window
-	exitSignal chan struct{}
-	mutex      sync.Mutex
-	wg         sync.WaitGroup
+	conn        *xgb.Conn
+	winTitle    string
+	winID       string
+	winClass    string
+	execCmd     string
+	execTimeout time.Duration
+	launching   bool
+	dropdown    bool
+	edge        string
+	widthPct    int
+	heightPct   int
+	animate     time.Duration
+	autoHide    bool
+	xinerama    bool
+	timeWin     xproto.Window
+	timeCh      chan xproto.Timestamp
+	lastTime    xproto.Timestamp
+	userTime    xproto.Timestamp
+	activeAtom  xproto.Atom
+	prevActive  xproto.Window
+	summon      bool
+	hideMode    string
+	fade        time.Duration
+	opacity     uint32
+	hasOpacity  bool
+	placement   windowPlacement
+	socketPath  string
+	listener    net.Listener
+	lockFile    *os.File
+	replace     bool
+	forward     string
+	logLevel    string
+	logFormat   string
+	logFile     string
+	shortcut    *systray.MenuItem
+	targetWin   xproto.Window
+	isVisible   bool
+	keyCombo    string
+	keyMods     uint16
+	keyCode     xproto.Keycode
+	root        xproto.Window
+	ctx         context.Context
+	cancel      context.CancelFunc
+	commands    chan func()
+	readerDone  chan struct{}
+	connected   bool
+	connClosed  bool
+	idStale     bool
+	stopOnce    sync.Once
+	quit        func()
+	dial        func() (*xgb.Conn, error)
+	mutex       sync.Mutex
+	wg          sync.WaitGroup
 }
 
 var state AppState
 
 func initAppState() {
 	state = AppState{
-		exitSignal: make(chan struct{}),
+		commands: make(chan func()),
+		timeCh:   make(chan xproto.Timestamp, 1),
+		quit:     systray.Quit,
+		dial:     xgb.NewConn,
 	}
+	state.ctx, state.cancel = context.WithCancel(context.Background())
+}
+
+// --------------------------------- logging ---------------------------------
+func setupLogging(level, format, file string) error {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return fmt.Errorf("invalid -log-level '%s', expected debug, info, warn or error", level)
+	}
+
+	var out io.Writer = os.Stdout
+	if file != "" {
+		f, err := os.OpenFile(file, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return fmt.Errorf("cannot open log file: %v", err)
+		}
+		out = f
+	}
+
+	opts := &slog.HandlerOptions{Level: lvl}
+	switch format {
+	case "text":
+		slog.SetDefault(slog.New(slog.NewTextHandler(out, opts)))
+	case "json":
+		slog.SetDefault(slog.New(slog.NewJSONHandler(out, opts)))
+	default:
+		return fmt.Errorf("invalid -log-format '%s', expected text or json", format)
+	}
+	return nil
+}
+
+func windowAttr(window xproto.Window) slog.Attr {
+	return slog.String("window", fmt.Sprintf("0x%x", window))
 }
 
 // --------------------------------- window ---------------------------------
-func findWindowRecursive(conn *xgb.Conn, parent xproto.Window, title string) (xproto.Window, error) {
-	nameReply, err := xproto.GetProperty(conn, false, parent,
-		xproto.AtomWmName, xproto.AtomString, 0, (1<<32)-1).Reply()
 
-	if err == nil && nameReply != nil && nameReply.ValueLen > 0 {
+// windowMatch describes the window to look for. Empty fields are ignored, but
+// at least one of them must be set for a window to match.
+type windowMatch struct {
+	title string
+	class string
+	pid   uint32
+}
+
+func (m windowMatch) isEmpty() bool {
+	return m.title == "" && m.class == "" && m.pid == 0
+}
+
+func (m windowMatch) String() string {
+	var parts []string
+	if m.title != "" {
+		parts = append(parts, fmt.Sprintf("title containing '%s'", m.title))
+	}
+	if m.class != "" {
+		parts = append(parts, fmt.Sprintf("class '%s'", m.class))
+	}
+	if m.pid != 0 {
+		parts = append(parts, fmt.Sprintf("pid %d", m.pid))
+	}
+	return strings.Join(parts, " and ")
+}
+
+func internAtom(conn *xgb.Conn, name string) (xproto.Atom, error) {
+	reply, err := xproto.InternAtom(conn, false, uint16(len(name)), name).Reply()
+	if err != nil {
+		return 0, err
+	}
+	return reply.Atom, nil
+}
+
+func getWindowClass(conn *xgb.Conn, window xproto.Window) []string {
+	reply, err := xproto.GetProperty(conn, false, window,
+		xproto.AtomWmClass, xproto.AtomString, 0, (1<<32)-1).Reply()
+	if err != nil || reply == nil || reply.ValueLen == 0 {
+		return nil
+	}
+	return strings.Split(strings.TrimRight(string(reply.Value), "\x00"), "\x00")
+}
+
+func getWindowPID(conn *xgb.Conn, window xproto.Window) uint32 {
+	pidAtom, err := internAtom(conn, "_NET_WM_PID")
+	if err != nil {
+		return 0
+	}
+	reply, err := xproto.GetProperty(conn, false, window,
+		pidAtom, xproto.AtomCardinal, 0, 1).Reply()
+	if err != nil || reply == nil || len(reply.Value) < 4 {
+		return 0
+	}
+	return xgb.Get32(reply.Value)
+}
+
+func windowMatches(conn *xgb.Conn, window xproto.Window, match windowMatch) bool {
+	if match.isEmpty() {
+		return false
+	}
+
+	if match.title != "" {
+		nameReply, err := xproto.GetProperty(conn, false, window,
+			xproto.AtomWmName, xproto.AtomString, 0, (1<<32)-1).Reply()
+		if err != nil || nameReply == nil || nameReply.ValueLen == 0 {
+			return false
+		}
 		windowName := string(nameReply.Value)
-		if strings.Contains(strings.ToLower(windowName), strings.ToLower(title)) {
-			log.Printf("Window title match: %s\n", windowName)
-			return parent, nil
+		if !strings.Contains(strings.ToLower(windowName), strings.ToLower(match.title)) {
+			return false
+		}
+		slog.Debug("Window title match", windowAttr(window), "title", windowName)
+	}
+
+	if match.class != "" {
+		found := false
+		for _, class := range getWindowClass(conn, window) {
+			if strings.EqualFold(class, match.class) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
 		}
 	}
 
+	if match.pid != 0 && getWindowPID(conn, window) != match.pid {
+		return false
+	}
+
+	return true
+}
+
+func findWindowRecursive(conn *xgb.Conn, parent xproto.Window, match windowMatch) (xproto.Window, error) {
+	if windowMatches(conn, parent, match) {
+		return parent, nil
+	}
+
 	treeReply, err := xproto.QueryTree(conn, parent).Reply()
 	if err != nil {
 		return 0, err
 	}
 
 	for _, child := range treeReply.Children {
-		if found, err := findWindowRecursive(conn, child, title); err == nil && found != 0 {
+		if found, err := findWindowRecursive(conn, child, match); err == nil && found != 0 {
 			return found, nil
 		}
 	}
@@ -70,23 +248,27 @@ func findWindowRecursive(conn *xgb.Conn, parent xproto.Window, title string) (xp
 	return 0, nil
 }
 
-func findWindowByTitle(conn *xgb.Conn, title string) (xproto.Window, error) {
+func findWindowByMatch(conn *xgb.Conn, match windowMatch) (xproto.Window, error) {
 	root := xproto.Setup(conn).DefaultScreen(conn).Root
-	target, err := findWindowRecursive(conn, root, title)
+	target, err := findWindowRecursive(conn, root, match)
 	if err != nil {
-		log.Printf("Error finding window: %v\n", err)
+		slog.Error("Error finding window", "match", match.String(), "error", err)
 		return 0, err
 	}
 
 	if target == 0 {
-		log.Printf("No window found with title containing '%s'\n", title)
+		slog.Warn("No window found", "match", match.String())
 		return 0, fmt.Errorf("window not found")
 	}
 
-	log.Printf("Found window with title containing '%s'\n", title)
+	slog.Info("Found window", windowAttr(target), "match", match.String())
 	return target, nil
 }
 
+func findWindowByTitle(conn *xgb.Conn, title string) (xproto.Window, error) {
+	return findWindowByMatch(conn, windowMatch{title: title})
+}
+
 func findWindowByID(conn *xgb.Conn, windowIDStr string) (xproto.Window, error) {
 	var windowID uint64
 	var err error
@@ -98,7 +280,7 @@ func findWindowByID(conn *xgb.Conn, windowIDStr string) (xproto.Window, error) {
 	}
 
 	if err != nil {
-		log.Printf("Invalid window ID format: %s\n", windowIDStr)
+		slog.Debug("Invalid window ID format", "id", windowIDStr)
 		return 0, err
 	}
 
@@ -106,18 +288,57 @@ func findWindowByID(conn *xgb.Conn, windowIDStr string) (xproto.Window, error) {
 
 	_, err = xproto.GetWindowAttributes(conn, window).Reply()
 	if err != nil {
-		log.Printf("Window ID exists but cannot get attributes: 0x%x\n", window)
+		slog.Warn("Window ID exists but cannot get attributes", windowAttr(window), "error", err)
 		return 0, err
 	}
 
-	log.Printf("Found window with ID: 0x%x\n", window)
+	slog.Info("Found window by ID", windowAttr(window))
 	return window, nil
 }
 
+// checkWindowFlags rejects -active and -under-cursor together with each
+// other or with -title, -class or -id, which would otherwise silently win.
+func checkWindowFlags(active, underCursor bool) error {
+	if !active && !underCursor {
+		return nil
+	}
+	given := 0
+	for _, set := range []bool{state.winTitle != "" || state.winClass != "", state.winID != "", active, underCursor} {
+		if set {
+			given++
+		}
+	}
+	if given > 1 {
+		return fmt.Errorf("use -active or -under-cursor on their own, not with -title, -class, -id or each other")
+	}
+	return nil
+}
+
+// resolveWindowFlag turns -active or -under-cursor into a window ID at
+// startup, so from then on the target is handled exactly like -id.
+func resolveWindowFlag(active, underCursor bool) (string, error) {
+	conn, err := xgb.NewConn()
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+
+	var window xproto.Window
+	if active {
+		window, err = target.Active(conn)
+	} else if underCursor {
+		window, err = target.UnderCursor(conn)
+	}
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("0x%x", uint32(window)), nil
+}
+
 func findWindow(conn *xgb.Conn, identifier string) (xproto.Window, error) {
 	window, err := findWindowByID(conn, identifier)
 	if err != nil {
-		log.Printf("Trying to find window by title instead...\n")
+		slog.Debug("Trying to find window by title instead", "title", identifier)
 		return findWindowByTitle(conn, identifier)
 	}
 	return window, nil
@@ -128,58 +349,242 @@ func setWindowVisibility(conn *xgb.Conn, window xproto.Window, visible bool) {
 	defer state.mutex.Unlock()
 
 	if visible {
-		xproto.MapWindow(conn, window)
-		log.Println("Window mapped (shown)")
+		fadeTo := beginFadeIn(conn, window)
+		if state.dropdown {
+			showDropdown(conn, window)
+		} else {
+			restorePlacement(conn, window, false)
+			unhideWithMode(conn, window)
+			xproto.MapWindow(conn, window)
+			waitForMapped(conn, window)
+			restorePlacement(conn, window, true)
+			activateWindow(conn, window)
+		}
+		finishFadeIn(conn, window, fadeTo)
+		slog.Info("Window shown", windowAttr(window), "event", "show")
 		state.isVisible = true
 	} else {
-		xproto.UnmapWindow(conn, window)
-		log.Println("Window unmapped (hidden)")
+		wasActive := getActiveWindow(conn) == window
+		if state.dropdown {
+			hideDropdown(conn, window)
+		} else {
+			savePlacement(conn, window)
+		}
+		fadeOut(conn, window)
+		hideWithMode(conn, window)
+		if state.fade > 0 && state.hideMode != hideModeOpacity {
+			// Leave the hidden window as opaque as it was, in case something
+			// else shows it.
+			setCardinal(conn, window, "_NET_WM_WINDOW_OPACITY", savedOpacity())
+		}
+		slog.Info("Window hidden", windowAttr(window), "event", "hide", "mode", state.hideMode)
 		state.isVisible = false
+		if wasActive {
+			restorePreviousFocus(conn)
+		}
 	}
 }
 
-func toggleWindowVisibility() {
-	attrs, err := xproto.GetWindowAttributes(state.conn, state.targetWin).Reply()
+// currentVisibility reports whether the target window is mapped. ok is false
+// when there is no target window (anymore).
+func (s *AppState) currentVisibility() (visible bool, ok bool) {
+	if s.targetWin == 0 {
+		s.updateTargetWindow()
+	}
+	if s.targetWin == 0 {
+		return false, false
+	}
+
+	attrs, err := xproto.GetWindowAttributes(s.conn, s.targetWin).Reply()
 	if err != nil {
-		log.Printf("Error getting window attributes: %v\n", err)
-		updateTargetWindow()
-		return
+		slog.Warn("Error getting window attributes", windowAttr(s.targetWin), "error", err)
+		s.updateTargetWindow()
+		return false, false
+	}
+
+	if attrs.MapState == xproto.MapStateUnmapped {
+		return false, true
+	}
+	return !isHiddenByMode(s.conn, s.targetWin), true
+}
+
+func changeWindowVisibility(visible bool) error {
+	current, ok := state.currentVisibility()
+	if !ok {
+		if visible && state.targetWin == 0 && state.execCmd != "" {
+			return launchAndShowTarget()
+		}
+		return fmt.Errorf("window not found")
 	}
 
-	isCurrentlyVisible := attrs.MapState != 0
-	setWindowVisibility(state.conn, state.targetWin, !isCurrentlyVisible)
+	if current != visible {
+		setWindowVisibility(state.conn, state.targetWin, visible)
+	}
 
-	updateSystrayTooltip()
+	state.updateSystrayTooltip()
+	return nil
 }
 
-func updateSystrayTooltip() {
-	state.mutex.Lock()
-	defer state.mutex.Unlock()
+func toggleWindowVisibility() error {
+	visible, ok := state.currentVisibility()
+	if !ok {
+		if state.targetWin == 0 && state.execCmd != "" {
+			return launchAndShowTarget()
+		}
+		return fmt.Errorf("window not found")
+	}
 
-	if state.isVisible {
-		systray.SetTooltip(fmt.Sprintf("Hide %s", formatWindowDescription()))
+	return changeWindowVisibility(!visible)
+}
+
+func (s *AppState) updateSystrayTooltip() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if !s.connected {
+		systray.SetTooltip(fmt.Sprintf("%s: X connection lost, reconnecting...", s.formatWindowDescription()))
+	} else if s.isVisible {
+		systray.SetTooltip(fmt.Sprintf("Hide %s", s.formatWindowDescription()))
 	} else {
-		systray.SetTooltip(fmt.Sprintf("Show %s", formatWindowDescription()))
+		systray.SetTooltip(fmt.Sprintf("Show %s", s.formatWindowDescription()))
 	}
 }
 
-func formatWindowDescription() string {
-	if state.winTitle != "" {
-		return fmt.Sprintf("'%s'", state.winTitle)
+func (s *AppState) formatWindowDescription() string {
+	if s.winTitle != "" {
+		return fmt.Sprintf("'%s'", s.winTitle)
+	}
+	if s.winClass != "" && s.targetWin == 0 {
+		return fmt.Sprintf("class '%s'", s.winClass)
 	}
-	return fmt.Sprintf("window 0x%x", state.targetWin)
+	return fmt.Sprintf("window 0x%x", s.targetWin)
 }
 
-func updateTargetWindow() {
+func (s *AppState) targetMatch() windowMatch {
+	return windowMatch{title: s.winTitle, class: s.winClass}
+}
+
+func (s *AppState) updateTargetWindow() {
 	var err error
-	if state.winTitle != "" {
-		state.targetWin, err = findWindowByTitle(state.conn, state.winTitle)
-	} else if state.winID != "" {
-		state.targetWin, err = findWindowByID(state.conn, state.winID)
+	if !s.targetMatch().isEmpty() {
+		s.targetWin, err = findWindowByMatch(s.conn, s.targetMatch())
+	} else if s.winID != "" && !s.idStale {
+		s.targetWin, err = findWindowByID(s.conn, s.winID)
 	}
 	if err != nil {
-		log.Printf("Error refreshing target window: %v\n", err)
+		slog.Warn("Error refreshing target window", "error", err)
+	}
+}
+
+// --------------------------------- launch ---------------------------------
+
+// launchTarget starts the -exec command and waits until its window appears
+// on conn. The window is matched by -title and -class when given, otherwise
+// by the PID of the spawned process.
+func launchTarget(conn *xgb.Conn) (xproto.Window, error) {
+	// "exec" makes the shell replace itself with the program, so the PID we
+	// get back is the one the program will advertise in _NET_WM_PID.
+	cmd := exec.Command("/bin/sh", "-c", "exec "+state.execCmd)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Start(); err != nil {
+		return 0, fmt.Errorf("failed to launch '%s': %v", state.execCmd, err)
+	}
+	slog.Info("Launched target", "event", "launch", "command", state.execCmd, "pid", cmd.Process.Pid)
+
+	exited := make(chan struct{})
+	go func() {
+		cmd.Wait()
+		close(exited)
+	}()
+
+	match := state.targetMatch()
+	if match.isEmpty() {
+		match.pid = uint32(cmd.Process.Pid)
+	}
+
+	root := xproto.Setup(conn).DefaultScreen(conn).Root
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	timeout := time.After(state.execTimeout)
+
+	for {
+		var window xproto.Window
+		err := guardConn(func() (err error) {
+			window, err = findWindowRecursive(conn, root, match)
+			return err
+		})
+		if err == errNotConnected {
+			return 0, err
+		}
+		if err == nil && window != 0 {
+			slog.Info("Launched window appeared", windowAttr(window), "event", "launch")
+			return window, nil
+		}
+
+		select {
+		case <-ticker.C:
+		case <-exited:
+			// Programs that hand off to an already running instance exit
+			// right away; keep polling in case that instance opens the window.
+			exited = nil
+		case <-timeout:
+			return 0, fmt.Errorf("no window with %s appeared within %v", match, state.execTimeout)
+		case <-state.ctx.Done():
+			return 0, fmt.Errorf("launch aborted")
+		}
+	}
+}
+
+// launchAndShowTarget launches the target and returns right away. Waiting
+// for its window happens off the dispatcher, which meanwhile keeps handling
+// hotkeys, signals and control requests; only showing the window is handed
+// back to it. It runs on the dispatcher.
+func launchAndShowTarget() error {
+	state.mutex.Lock()
+	if state.launching {
+		state.mutex.Unlock()
+		slog.Debug("Launch already in progress")
+		return fmt.Errorf("launch already in progress")
 	}
+	state.launching = true
+	state.mutex.Unlock()
+
+	conn := state.conn
+	state.wg.Add(1)
+	go func() {
+		defer state.wg.Done()
+		defer func() {
+			state.mutex.Lock()
+			state.launching = false
+			state.mutex.Unlock()
+		}()
+
+		window, err := launchTarget(conn)
+		if err != nil {
+			slog.Error("Error launching target", "command", state.execCmd, "error", err)
+			return
+		}
+
+		err = state.runOnDispatcher(func() error {
+			// The window ID means nothing on a connection made since.
+			if state.conn != conn {
+				return errNotConnected
+			}
+			state.mutex.Lock()
+			state.targetWin = window
+			state.placement = windowPlacement{}
+			state.mutex.Unlock()
+
+			setWindowVisibility(state.conn, state.targetWin, true)
+			state.updateSystrayTooltip()
+			return nil
+		})
+		if err != nil && err != errShuttingDown {
+			slog.Warn("Cannot show launched window", windowAttr(window), "error", err)
+		}
+	}()
+	return nil
 }
 
 func listWindows(conn *xgb.Conn) {
@@ -207,223 +612,1501 @@ func listWindows(conn *xgb.Conn) {
 	fmt.Println("----------------")
 }
 
-// --------------------------------- tray ---------------------------------
-func onSystrayReady() {
-	systray.SetIcon(icon.Data)
-	systray.SetTitle(state.winTitle)
-	systray.SetTooltip(fmt.Sprintf("Toggle visibility of %s", formatWindowDescription()))
+// --------------------------------- dropdown ---------------------------------
+const (
+	netWmStateRemove = 0
+	netWmStateAdd    = 1
+
+	// Source indication for EWMH client messages: requests coming from a
+	// pager or another tool acting on behalf of the user.
+	sourcePager = 2
+)
 
-	mToggle := systray.AddMenuItem(fmt.Sprintf("Toggle %s", formatWindowDescription()), "Toggle window visibility")
+type monitorRect struct {
+	x, y, width, height int
+}
 
-	if state.keyCombo != "" {
-		mShortcut := systray.AddMenuItem(fmt.Sprintf("Shortcut: %s", state.keyCombo), "Keyboard shortcut")
-		mShortcut.Disable()
+func sendClientMessage(conn *xgb.Conn, window xproto.Window, messageType string, data ...uint32) error {
+	atom, err := internAtom(conn, messageType)
+	if err != nil {
+		return err
 	}
 
-	mQuit := systray.AddMenuItem("Quit", "Quit the application")
+	for len(data) < 5 {
+		data = append(data, 0)
+	}
 
-	go func() {
-		for {
-			select {
-			case <-mToggle.ClickedCh:
-				toggleWindowVisibility()
-			case <-mQuit.ClickedCh:
-				cleanupAndExit()
-				return
-			case <-state.exitSignal:
-				return
-			}
+	root := xproto.Setup(conn).DefaultScreen(conn).Root
+	event := xproto.ClientMessageEvent{
+		Format: 32,
+		Window: window,
+		Type:   atom,
+		Data:   xproto.ClientMessageDataUnionData32New(data),
+	}
+	return xproto.SendEventChecked(conn, false, root,
+		xproto.EventMaskSubstructureNotify|xproto.EventMaskSubstructureRedirect,
+		string(event.Bytes())).Check()
+}
+
+func setWindowStates(conn *xgb.Conn, window xproto.Window, action uint32, states ...string) {
+	for _, name := range states {
+		atom, err := internAtom(conn, name)
+		if err != nil {
+			slog.Warn("Error interning atom", "atom", name, "error", err)
+			continue
 		}
-	}()
+		if err := sendClientMessage(conn, window, "_NET_WM_STATE", action, uint32(atom), 0, sourcePager); err != nil {
+			slog.Warn("Error setting window state", windowAttr(window), "state", name, "error", err)
+		}
+	}
 }
 
-func onSystrayExit() {
-	log.Println("Exiting...")
+func monitorUnderPointer(conn *xgb.Conn) monitorRect {
+	screen := xproto.Setup(conn).DefaultScreen(conn)
+	full := monitorRect{0, 0, int(screen.WidthInPixels), int(screen.HeightInPixels)}
+
+	if !state.xinerama {
+		return full
+	}
+
+	pointer, err := xproto.QueryPointer(conn, screen.Root).Reply()
+	if err != nil {
+		return full
+	}
+
+	screens, err := xinerama.QueryScreens(conn).Reply()
+	if err != nil {
+		return full
+	}
+
+	px, py := int(pointer.RootX), int(pointer.RootY)
+	for _, info := range screens.ScreenInfo {
+		mon := monitorRect{int(info.XOrg), int(info.YOrg), int(info.Width), int(info.Height)}
+		if px >= mon.x && px < mon.x+mon.width && py >= mon.y && py < mon.y+mon.height {
+			return mon
+		}
+	}
+	return full
 }
 
-func cleanupAndExit() {
-	close(state.exitSignal)
-	systray.Quit()
-	state.wg.Wait()
-	if state.conn != nil {
-		state.conn.Close()
+// dropdownGeometry returns where the window sits when shown on mon, and
+// where it slides in from: just past the configured edge.
+func dropdownGeometry(mon monitorRect) (shown, hidden monitorRect) {
+	width := mon.width * state.widthPct / 100
+	height := mon.height * state.heightPct / 100
+
+	shown = monitorRect{mon.x + (mon.width-width)/2, mon.y + (mon.height-height)/2, width, height}
+	hidden = shown
+
+	switch state.edge {
+	case "top":
+		shown.y = mon.y
+		hidden.y = mon.y - height
+	case "bottom":
+		shown.y = mon.y + mon.height - height
+		hidden.y = mon.y + mon.height
+	case "left":
+		shown.x = mon.x
+		hidden.x = mon.x - width
+	case "right":
+		shown.x = mon.x + mon.width - width
+		hidden.x = mon.x + mon.width
 	}
+	return shown, hidden
 }
 
-// --------------------------------- hotkey ---------------------------------
-func parseKeyCombo(combo string) (uint16, byte, error) {
-	parts := strings.Split(strings.ToLower(combo), "+")
-	if len(parts) < 1 {
-		return 0, 0, fmt.Errorf("invalid key combination format")
-	}
-
-	var mods uint16 = 0
-
-	keyName := parts[len(parts)-1]
-
-	if len(keyName) == 1 && keyName[0] >= 'a' && keyName[0] <= 'z' {
-		for i := 0; i < len(parts)-1; i++ {
-			switch parts[i] {
-			case "ctrl":
-				mods |= xproto.ModMaskControl
-			case "shift":
-				mods |= xproto.ModMaskShift
-			case "alt":
-				mods |= xproto.ModMask1
-			case "super":
-				mods |= xproto.ModMask4
-			default:
-				log.Printf("Warning: Unknown modifier '%s'\n", parts[i])
-			}
-		}
-		return mods, keyName[0], nil
+func moveResizeWindow(conn *xgb.Conn, window xproto.Window, rect monitorRect) {
+	xproto.ConfigureWindow(conn, window,
+		xproto.ConfigWindowX|xproto.ConfigWindowY|xproto.ConfigWindowWidth|xproto.ConfigWindowHeight,
+		[]uint32{uint32(int32(rect.x)), uint32(int32(rect.y)), uint32(rect.width), uint32(rect.height)})
+}
+
+func slideWindow(conn *xgb.Conn, window xproto.Window, from, to monitorRect) {
+	const frame = 10 * time.Millisecond
+
+	steps := int(state.animate / frame)
+	for i := 1; i <= steps; i++ {
+		moveResizeWindow(conn, window, monitorRect{
+			x:      from.x + (to.x-from.x)*i/steps,
+			y:      from.y + (to.y-from.y)*i/steps,
+			width:  to.width,
+			height: to.height,
+		})
+		time.Sleep(frame)
+	}
+	moveResizeWindow(conn, window, to)
+}
+
+func showDropdown(conn *xgb.Conn, window xproto.Window) {
+	shown, hidden := dropdownGeometry(monitorUnderPointer(conn))
+
+	start := shown
+	if state.animate > 0 {
+		start = hidden
+	}
+	moveResizeWindow(conn, window, start)
+	if desktop, ok := getCardinal(conn, state.root, "_NET_CURRENT_DESKTOP"); ok {
+		setCardinal(conn, window, "_NET_WM_DESKTOP", desktop)
 	}
+	unhideWithMode(conn, window)
+	xproto.MapWindow(conn, window)
+
+	setWindowStates(conn, window, netWmStateAdd,
+		"_NET_WM_STATE_ABOVE", "_NET_WM_STATE_SKIP_TASKBAR", "_NET_WM_STATE_SKIP_PAGER")
+
+	// Window managers may place the window themselves while mapping it,
+	// so the final geometry is applied once more afterwards.
+	slideWindow(conn, window, start, shown)
+	activateWindow(conn, window)
 
-	return 0, 0, fmt.Errorf("unsupported key: %s, only a-z keys are supported", keyName)
+	if state.autoHide {
+		xproto.ChangeWindowAttributes(conn, window, xproto.CwEventMask,
+			[]uint32{xproto.EventMaskFocusChange})
+	}
 }
 
-func getKeycodeFromChar(conn *xgb.Conn, char byte) (xproto.Keycode, error) {
-	reply, err := xproto.GetKeyboardMapping(conn, 8, 248).Reply()
+func hideDropdown(conn *xgb.Conn, window xproto.Window) {
+	if state.animate <= 0 {
+		return
+	}
+
+	geom, err := xproto.GetGeometry(conn, xproto.Drawable(window)).Reply()
 	if err != nil {
-		return 0, err
+		return
 	}
+	_, hidden := dropdownGeometry(monitorUnderPointer(conn))
 
-	keysymsPerKeycode := int(reply.KeysymsPerKeycode)
-	for i := 0; i < 248; i++ {
-		for j := 0; j < 2; j++ {
-			idx := i*keysymsPerKeycode + j
-			if idx < len(reply.Keysyms) {
-				keysym := uint32(reply.Keysyms[idx])
-				if keysym == uint32(char) || keysym == uint32(char-32) {
-					return xproto.Keycode(i + 8), nil
-				}
-			}
-		}
+	// The client window is usually reparented into a frame, so its position
+	// is taken relative to the root window.
+	root := xproto.Setup(conn).DefaultScreen(conn).Root
+	pos, err := xproto.TranslateCoordinates(conn, window, root, 0, 0).Reply()
+	if err != nil {
+		return
 	}
+	current := monitorRect{int(pos.DstX), int(pos.DstY), int(geom.Width), int(geom.Height)}
+	slideWindow(conn, window, current, hidden)
+}
+
+func handleFocusOut(e xproto.FocusOutEvent) {
+	if !state.autoHide || e.Event != state.targetWin {
+		return
+	}
+	// Grabs (including our own hotkey) and focus moving into a child of
+	// the window are not a real focus loss.
+	if e.Mode != xproto.NotifyModeNormal || e.Detail == xproto.NotifyDetailInferior {
+		return
+	}
+
+	state.mutex.Lock()
+	visible := state.isVisible
+	state.mutex.Unlock()
 
-	return 0, fmt.Errorf("keycode not found for char: %c", char)
+	if visible {
+		slog.Info("Drop-down window lost focus, hiding", windowAttr(e.Event), "event", "focus-out")
+		setWindowVisibility(state.conn, state.targetWin, false)
+		state.updateSystrayTooltip()
+	}
 }
 
-func setupKeyboardShortcut() error {
-	state.root = xproto.Setup(state.conn).DefaultScreen(state.conn).Root
+// --------------------------------- focus ---------------------------------
+
+// setupFocusTracking creates the helper window used to obtain server
+// timestamps and starts following _NET_ACTIVE_WINDOW on the root window.
+func setupFocusTracking(conn *xgb.Conn) error {
+	screen := xproto.Setup(conn).DefaultScreen(conn)
+	state.root = screen.Root
 
 	var err error
-	var keyChar byte
-	state.keyMods, keyChar, err = parseKeyCombo(state.keyCombo)
+	state.activeAtom, err = internAtom(conn, "_NET_ACTIVE_WINDOW")
 	if err != nil {
 		return err
 	}
 
-	state.keyCode, err = getKeycodeFromChar(state.conn, keyChar)
+	state.timeWin, err = xproto.NewWindowId(conn)
+	if err != nil {
+		return err
+	}
+	err = xproto.CreateWindowChecked(conn, 0, state.timeWin, screen.Root,
+		-1, -1, 1, 1, 0, xproto.WindowClassInputOnly, screen.RootVisual,
+		xproto.CwOverrideRedirect|xproto.CwEventMask,
+		[]uint32{1, xproto.EventMaskPropertyChange}).Check()
 	if err != nil {
 		return err
 	}
 
-	err = xproto.GrabKeyChecked(
-		state.conn,
-		true,
-		state.root,
-		state.keyMods,
-		state.keyCode,
-		xproto.GrabModeAsync,
-		xproto.GrabModeAsync,
-	).Check()
+	err = xproto.ChangeWindowAttributesChecked(conn, screen.Root, xproto.CwEventMask,
+		[]uint32{xproto.EventMaskPropertyChange}).Check()
+	if err != nil {
+		return err
+	}
 
-	return err
+	trackActiveWindow(conn)
+	return nil
 }
 
-func listenForKeyEvents() {
-	state.wg.Add(1)
-	defer state.wg.Done()
-	for {
+// serverTime returns a fresh X server timestamp by touching a property on
+// the helper window and waiting for the resulting PropertyNotify, which the
+// event reader hands over directly.
+func serverTime(conn *xgb.Conn) xproto.Timestamp {
+	atom, err := internAtom(conn, "_GWCTL_TIMESTAMP")
+	if err == nil && state.timeWin != 0 {
+		// Drop a timestamp left over from an earlier request that timed out.
 		select {
-		case <-state.exitSignal:
-			return
+		case <-state.timeCh:
 		default:
-			ev, err := state.conn.WaitForEvent()
-			if err != nil {
-				log.Printf("Error waiting for X event: %v\n", err)
-				continue
-			}
-
-			switch e := ev.(type) {
-			case xproto.KeyPressEvent:
-				if e.Detail == state.keyCode && e.State == state.keyMods {
-					log.Println("Shortcut detected, toggling window visibility")
-					toggleWindowVisibility()
-				}
-			}
+		}
+		xproto.ChangeProperty(conn, xproto.PropModeAppend, state.timeWin,
+			atom, xproto.AtomString, 8, 0, nil)
+		select {
+		case t := <-state.timeCh:
+			return t
+		case <-time.After(200 * time.Millisecond):
 		}
 	}
+
+	state.mutex.Lock()
+	defer state.mutex.Unlock()
+	return state.lastTime
 }
 
-// --------------------------------- main ---------------------------------
-func main() {
-	initAppState()
+func setUserTime(t xproto.Timestamp) {
+	state.mutex.Lock()
+	defer state.mutex.Unlock()
+	state.userTime = t
+}
 
-	log.SetOutput(os.Stdout)
-	log.SetPrefix("[WindowToggler] ")
+func handlePropertyNotify(e xproto.PropertyNotifyEvent) {
+	state.mutex.Lock()
+	state.lastTime = e.Time
+	state.mutex.Unlock()
 
-	flag.StringVar(&state.winTitle, "title", "", "Window title to control")
-	flag.StringVar(&state.winID, "id", "", "Window ID to control (decimal or hex with 0x prefix)")
-	flag.StringVar(&state.keyCombo, "key", "", "Keyboard shortcut (e.g., 'ctrl+shift+alt+a')")
-	flag.Parse()
+	if e.Window == state.root && e.Atom == state.activeAtom {
+		trackActiveWindow(state.conn)
+	}
+}
 
-	if state.winTitle == "" && state.winID == "" {
-		fmt.Println("Error: Either -title or -id must be specified")
-		fmt.Println("Usage: ")
-		fmt.Println("  To control by title: go run main.go -title \"Firefox\" [-key \"ctrl+shift+alt+a\"]")
-		fmt.Println("  To control by ID:    go run main.go -id 0x1234567 [-key \"ctrl+shift+alt+a\"]")
+func getActiveWindow(conn *xgb.Conn) xproto.Window {
+	if state.activeAtom == 0 {
+		return 0
+	}
+	root := xproto.Setup(conn).DefaultScreen(conn).Root
+	reply, err := xproto.GetProperty(conn, false, root,
+		state.activeAtom, xproto.AtomWindow, 0, 1).Reply()
+	if err != nil || reply == nil || len(reply.Value) < 4 {
+		return 0
+	}
+	return xproto.Window(xgb.Get32(reply.Value))
+}
+
+// trackActiveWindow remembers the last active window other than the target,
+// so focus can be handed back to it when the target is hidden.
+func trackActiveWindow(conn *xgb.Conn) {
+	active := getActiveWindow(conn)
+	if active == 0 || active == state.targetWin {
 		return
 	}
 
-	var err error
-	state.conn, err = xgb.NewConn()
+	state.mutex.Lock()
+	defer state.mutex.Unlock()
+	state.prevActive = active
+}
+
+func waitForMapped(conn *xgb.Conn, window xproto.Window) {
+	deadline := time.Now().Add(500 * time.Millisecond)
+	for time.Now().Before(deadline) {
+		attrs, err := xproto.GetWindowAttributes(conn, window).Reply()
+		if err != nil || attrs.MapState == xproto.MapStateViewable {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// activateWindow raises the window and asks the window manager to focus it.
+// The request carries the timestamp of the user action that caused it, as
+// window managers with focus stealing prevention refuse CurrentTime.
+func activateWindow(conn *xgb.Conn, window xproto.Window) {
+	xproto.ConfigureWindow(conn, window, xproto.ConfigWindowStackMode, []uint32{xproto.StackModeAbove})
+
+	timestamp := state.userTime
+	if timestamp == 0 {
+		timestamp = state.lastTime
+	}
+
+	err := sendClientMessage(conn, window, "_NET_ACTIVE_WINDOW",
+		sourcePager, uint32(timestamp), uint32(getActiveWindow(conn)))
 	if err != nil {
-		log.Fatalf("Cannot open display: %v\n", err)
+		slog.Warn("Error activating window", windowAttr(window), "error", err)
+	}
+}
+
+// restorePreviousFocus is called with state.mutex held.
+func restorePreviousFocus(conn *xgb.Conn) {
+	prev := state.prevActive
+	if prev == 0 || prev == state.targetWin {
+		return
+	}
+
+	attrs, err := xproto.GetWindowAttributes(conn, prev).Reply()
+	if err != nil || attrs.MapState != xproto.MapStateViewable {
+		slog.Debug("Previously active window is gone, leaving focus to the window manager", windowAttr(prev))
+		return
+	}
+
+	slog.Debug("Restoring focus", windowAttr(prev), "event", "focus-restore")
+	activateWindow(conn, prev)
+}
+
+// --------------------------------- placement ---------------------------------
+
+// windowPlacement is what window managers tend to forget when a window is
+// unmapped and mapped again: where it was, on which desktop and whether it
+// was maximized.
+type windowPlacement struct {
+	valid      bool
+	rect       monitorRect
+	desktop    uint32
+	hasDesktop bool
+	maxVert    bool
+	maxHorz    bool
+}
+
+func getCardinal(conn *xgb.Conn, window xproto.Window, name string) (uint32, bool) {
+	atom, err := internAtom(conn, name)
+	if err != nil {
+		return 0, false
+	}
+	reply, err := xproto.GetProperty(conn, false, window,
+		atom, xproto.AtomCardinal, 0, 1).Reply()
+	if err != nil || reply == nil || len(reply.Value) < 4 {
+		return 0, false
+	}
+	return xgb.Get32(reply.Value), true
+}
+
+func setCardinal(conn *xgb.Conn, window xproto.Window, name string, value uint32) {
+	atom, err := internAtom(conn, name)
+	if err != nil {
+		return
+	}
+	buf := make([]byte, 4)
+	xgb.Put32(buf, value)
+	xproto.ChangeProperty(conn, xproto.PropModeReplace, window,
+		atom, xproto.AtomCardinal, 32, 1, buf)
+}
+
+func getWindowStates(conn *xgb.Conn, window xproto.Window) map[string]bool {
+	states := make(map[string]bool)
+
+	atom, err := internAtom(conn, "_NET_WM_STATE")
+	if err != nil {
+		return states
+	}
+	reply, err := xproto.GetProperty(conn, false, window,
+		atom, xproto.AtomAtom, 0, (1<<32)-1).Reply()
+	if err != nil || reply == nil {
+		return states
+	}
+
+	for i := 0; i+4 <= len(reply.Value); i += 4 {
+		nameReply, err := xproto.GetAtomName(conn, xproto.Atom(xgb.Get32(reply.Value[i:]))).Reply()
+		if err == nil {
+			states[nameReply.Name] = true
+		}
+	}
+	return states
+}
+
+// frameExtents returns the left and top decoration sizes the window manager
+// added around the window.
+func frameExtents(conn *xgb.Conn, window xproto.Window) (int, int) {
+	atom, err := internAtom(conn, "_NET_FRAME_EXTENTS")
+	if err != nil {
+		return 0, 0
+	}
+	reply, err := xproto.GetProperty(conn, false, window,
+		atom, xproto.AtomCardinal, 0, 4).Reply()
+	if err != nil || reply == nil || len(reply.Value) < 16 {
+		return 0, 0
+	}
+	return int(xgb.Get32(reply.Value[0:])), int(xgb.Get32(reply.Value[8:]))
+}
+
+// savePlacement is called with state.mutex held, while the window is still mapped.
+func savePlacement(conn *xgb.Conn, window xproto.Window) {
+	geom, err := xproto.GetGeometry(conn, xproto.Drawable(window)).Reply()
+	if err != nil {
+		slog.Warn("Error getting window geometry", windowAttr(window), "error", err)
+		state.placement = windowPlacement{}
+		return
+	}
+	pos, err := xproto.TranslateCoordinates(conn, window, state.root, 0, 0).Reply()
+	if err != nil {
+		slog.Warn("Error getting window position", windowAttr(window), "error", err)
+		state.placement = windowPlacement{}
+		return
+	}
+
+	// Configure requests position the frame, not the client inside it.
+	left, top := frameExtents(conn, window)
+
+	placement := windowPlacement{
+		valid: true,
+		rect:  monitorRect{int(pos.DstX) - left, int(pos.DstY) - top, int(geom.Width), int(geom.Height)},
+	}
+	placement.desktop, placement.hasDesktop = getCardinal(conn, window, "_NET_WM_DESKTOP")
+
+	states := getWindowStates(conn, window)
+	placement.maxVert = states["_NET_WM_STATE_MAXIMIZED_VERT"]
+	placement.maxHorz = states["_NET_WM_STATE_MAXIMIZED_HORZ"]
+
+	state.placement = placement
+	slog.Debug("Saved placement", windowAttr(window), "placement", fmt.Sprintf("%+v", placement))
+}
+
+// restorePlacement reapplies the saved placement. Before mapping, the
+// desktop is written directly to the property as EWMH allows for withdrawn
+// windows; once mapped, the window manager is asked through client messages.
+func restorePlacement(conn *xgb.Conn, window xproto.Window, mapped bool) {
+	placement := state.placement
+
+	desktop, hasDesktop := placement.desktop, placement.hasDesktop
+	if state.summon {
+		desktop, hasDesktop = getCardinal(conn, state.root, "_NET_CURRENT_DESKTOP")
+	}
+
+	if !mapped {
+		if hasDesktop {
+			setCardinal(conn, window, "_NET_WM_DESKTOP", desktop)
+		}
+		if placement.valid {
+			moveResizeWindow(conn, window, placement.rect)
+		}
 		return
 	}
-	defer state.conn.Close()
 
-	if state.winTitle != "" {
-		state.targetWin, err = findWindowByTitle(state.conn, state.winTitle)
+	if hasDesktop {
+		if err := sendClientMessage(conn, window, "_NET_WM_DESKTOP", desktop, sourcePager); err != nil {
+			slog.Warn("Error restoring desktop", windowAttr(window), "desktop", desktop, "error", err)
+		}
+	}
+	if !placement.valid {
+		return
+	}
+
+	moveResizeWindow(conn, window, placement.rect)
+
+	var maximized []string
+	if placement.maxVert {
+		maximized = append(maximized, "_NET_WM_STATE_MAXIMIZED_VERT")
+	}
+	if placement.maxHorz {
+		maximized = append(maximized, "_NET_WM_STATE_MAXIMIZED_HORZ")
+	}
+	setWindowStates(conn, window, netWmStateAdd, maximized...)
+}
+
+// --------------------------------- hide modes ---------------------------------
+
+// Hide modes, for applications that misbehave when unmapped or that should
+// stay in the taskbar while hidden.
+const (
+	hideModeUnmap     = "unmap"     // unmap the window (default)
+	hideModeIconify   = "iconify"   // ask the window manager to iconify it (ICCCM)
+	hideModeOffscreen = "offscreen" // move it out of the visible screen area
+	hideModeHidden    = "hidden"    // set _NET_WM_STATE_HIDDEN
+	hideModeOpacity   = "opacity"   // make it fully transparent and lower it
+)
+
+const (
+	iconicState = 3
+
+	// Far enough to be off every monitor, close enough to fit in an int16.
+	offscreenPosition = -30000
+
+	opaque = 0xffffffff
+)
+
+func isValidHideMode(mode string) bool {
+	switch mode {
+	case hideModeUnmap, hideModeIconify, hideModeOffscreen, hideModeHidden, hideModeOpacity:
+		return true
+	}
+	return false
+}
+
+func getWMState(conn *xgb.Conn, window xproto.Window) uint32 {
+	atom, err := internAtom(conn, "WM_STATE")
+	if err != nil {
+		return 0
+	}
+	reply, err := xproto.GetProperty(conn, false, window, atom, atom, 0, 1).Reply()
+	if err != nil || reply == nil || len(reply.Value) < 4 {
+		return 0
+	}
+	return xgb.Get32(reply.Value)
+}
+
+// hideWithMode is called with state.mutex held.
+func hideWithMode(conn *xgb.Conn, window xproto.Window) {
+	switch state.hideMode {
+	case hideModeIconify:
+		if err := sendClientMessage(conn, window, "WM_CHANGE_STATE", iconicState); err != nil {
+			slog.Warn("Error iconifying window", windowAttr(window), "error", err)
+		}
+	case hideModeOffscreen:
+		pos := int32(offscreenPosition)
+		xproto.ConfigureWindow(conn, window, xproto.ConfigWindowX|xproto.ConfigWindowY,
+			[]uint32{uint32(pos), uint32(pos)})
+	case hideModeHidden:
+		setWindowStates(conn, window, netWmStateAdd, "_NET_WM_STATE_HIDDEN")
+	case hideModeOpacity:
+		// When fading, fadeOut already saved the opacity from before the fade.
+		if state.fade <= 0 {
+			state.opacity, state.hasOpacity = getCardinal(conn, window, "_NET_WM_WINDOW_OPACITY")
+		}
+		setCardinal(conn, window, "_NET_WM_WINDOW_OPACITY", 0)
+		// A transparent window still takes clicks, so keep it out of the way.
+		xproto.ConfigureWindow(conn, window, xproto.ConfigWindowStackMode, []uint32{xproto.StackModeBelow})
+	default:
+		xproto.UnmapWindow(conn, window)
+	}
+}
+
+// unhideWithMode undoes hideWithMode, apart from what mapping, restoring
+// the placement and activating the window already take care of. It is
+// called with state.mutex held.
+func unhideWithMode(conn *xgb.Conn, window xproto.Window) {
+	switch state.hideMode {
+	case hideModeHidden:
+		setWindowStates(conn, window, netWmStateRemove, "_NET_WM_STATE_HIDDEN")
+	case hideModeOpacity:
+		// When fading, finishFadeIn brings the opacity back gradually.
+		if state.fade <= 0 {
+			setCardinal(conn, window, "_NET_WM_WINDOW_OPACITY", savedOpacity())
+		}
+	}
+}
+
+// savedOpacity returns the opacity saved when hiding the window, or fully
+// opaque if it had none.
+func savedOpacity() uint32 {
+	if state.hasOpacity && state.opacity != 0 {
+		return state.opacity
+	}
+	return opaque
+}
+
+// isHiddenByMode tells whether a mapped window is hidden by the hide mode.
+func isHiddenByMode(conn *xgb.Conn, window xproto.Window) bool {
+	switch state.hideMode {
+	case hideModeIconify, hideModeHidden:
+		return getWMState(conn, window) == iconicState ||
+			getWindowStates(conn, window)["_NET_WM_STATE_HIDDEN"]
+	case hideModeOffscreen:
+		pos, err := xproto.TranslateCoordinates(conn, window, state.root, 0, 0).Reply()
+		return err == nil && int(pos.DstX) <= offscreenPosition/2 && int(pos.DstY) <= offscreenPosition/2
+	case hideModeOpacity:
+		opacity, ok := getCardinal(conn, window, "_NET_WM_WINDOW_OPACITY")
+		return ok && opacity == 0
+	}
+	return false
+}
+
+// --------------------------------- fade ---------------------------------
+
+// fadeWindow steps _NET_WM_WINDOW_OPACITY from one value to another over
+// state.fade. Without a compositing manager this just delays the change.
+func fadeWindow(conn *xgb.Conn, window xproto.Window, from, to uint32) {
+	const frame = 10 * time.Millisecond
+
+	steps := int64(state.fade / frame)
+	for i := int64(1); i <= steps; i++ {
+		value := int64(from) + (int64(to)-int64(from))*i/steps
+		setCardinal(conn, window, "_NET_WM_WINDOW_OPACITY", uint32(value))
+		time.Sleep(frame)
+	}
+	setCardinal(conn, window, "_NET_WM_WINDOW_OPACITY", to)
+}
+
+// fadeOut fades the window to transparent before it is hidden and saves the
+// opacity it had. It is called with state.mutex held.
+func fadeOut(conn *xgb.Conn, window xproto.Window) {
+	if state.fade <= 0 {
+		return
+	}
+	state.opacity, state.hasOpacity = getCardinal(conn, window, "_NET_WM_WINDOW_OPACITY")
+	fadeWindow(conn, window, savedOpacity(), 0)
+}
+
+// beginFadeIn makes the window transparent before it is shown and returns
+// the opacity to fade in to. It is called with state.mutex held.
+func beginFadeIn(conn *xgb.Conn, window xproto.Window) uint32 {
+	if state.fade <= 0 {
+		return 0
+	}
+
+	// A window hidden by opacity is at zero now; the others had their
+	// opacity put back when they were hidden.
+	to := savedOpacity()
+	if state.hideMode != hideModeOpacity {
+		if current, ok := getCardinal(conn, window, "_NET_WM_WINDOW_OPACITY"); ok && current != 0 {
+			to = current
+		} else {
+			to = opaque
+		}
+	}
+	setCardinal(conn, window, "_NET_WM_WINDOW_OPACITY", 0)
+	return to
+}
+
+// finishFadeIn fades a shown window in to the opacity from beginFadeIn. It
+// is called with state.mutex held.
+func finishFadeIn(conn *xgb.Conn, window xproto.Window, to uint32) {
+	if state.fade <= 0 {
+		return
+	}
+	fadeWindow(conn, window, 0, to)
+}
+
+// --------------------------------- tray ---------------------------------
+func onSystrayReady() {
+	systray.SetIcon(icon.Data)
+	systray.SetTitle(state.winTitle)
+	systray.SetTooltip(fmt.Sprintf("Toggle visibility of %s", state.formatWindowDescription()))
+
+	mToggle := systray.AddMenuItem(fmt.Sprintf("Toggle %s", state.formatWindowDescription()), "Toggle window visibility")
+
+	state.mutex.Lock()
+	state.shortcut = systray.AddMenuItem(fmt.Sprintf("Shortcut: %s", state.keyCombo), "Keyboard shortcut")
+	state.shortcut.Disable()
+	if state.keyCombo == "" {
+		state.shortcut.Hide()
+	}
+	state.mutex.Unlock()
+
+	mQuit := systray.AddMenuItem("Quit", "Quit the application")
+
+	state.wg.Add(1)
+	go func() {
+		defer state.wg.Done()
+		for {
+			select {
+			case <-mToggle.ClickedCh:
+				state.runOnDispatcher(func() error {
+					setUserTime(serverTime(state.conn))
+					return toggleWindowVisibility()
+				})
+			case <-mQuit.ClickedCh:
+				state.requestShutdown()
+				return
+			case <-state.ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+func onSystrayExit() {
+	slog.Info("Exiting", "event", "exit")
+}
+
+// requestShutdown may be called any number of times, from any goroutine.
+func (s *AppState) requestShutdown() {
+	s.stopOnce.Do(func() {
+		s.cancel()
+		s.quit()
+	})
+}
+
+// shutdown runs once systray.Run has returned. Everything that may use the
+// X connection is stopped before it is closed, and the connection is closed
+// before waiting for the reader, which is blocked in WaitForEvent until then.
+func (s *AppState) shutdown() {
+	s.requestShutdown()
+	stopControlSocket()
+	s.wg.Wait()
+	s.closeConn()
+	<-s.readerDone
+}
+
+// closeConn closes the X connection unless it is closed already. xgb closes
+// it by itself after a read error, from its own goroutine, so that can still
+// happen between the check and the close; closing it twice is recovered from.
+func (s *AppState) closeConn() {
+	s.mutex.Lock()
+	closed := s.connClosed
+	s.connClosed = true
+	s.mutex.Unlock()
+	if closed {
+		return
+	}
+
+	defer func() {
+		if r := recover(); r != nil && !isClosedChannelPanic(r) {
+			panic(r)
+		}
+	}()
+	s.conn.Close()
+}
+
+// --------------------------------- hotkey ---------------------------------
+func setupKeyboardShortcut() error {
+	state.root = xproto.Setup(state.conn).DefaultScreen(state.conn).Root
+
+	combo, err := keyboard.ParseCombo(state.keyCombo)
+	if err != nil {
+		return err
+	}
+
+	state.keyCode, err = keyboard.Keycode(state.conn, combo.Keysym)
+	if err != nil {
+		return err
+	}
+	state.keyMods = combo.Mask()
+
+	err = xproto.GrabKeyChecked(
+		state.conn,
+		true,
+		state.root,
+		state.keyMods,
+		state.keyCode,
+		xproto.GrabModeAsync,
+		xproto.GrabModeAsync,
+	).Check()
+
+	return err
+}
+
+// rebindShortcut replaces the grabbed hotkey. An empty combo removes it.
+func rebindShortcut(combo string) error {
+	if combo != "" {
+		if _, err := keyboard.ParseCombo(combo); err != nil {
+			return err
+		}
+	}
+
+	state.mutex.Lock()
+	defer state.mutex.Unlock()
+
+	oldCombo := state.keyCombo
+	if state.keyCode != 0 {
+		xproto.UngrabKey(state.conn, state.keyCode, state.root, state.keyMods)
+		state.keyCode, state.keyMods = 0, 0
+	}
+
+	state.keyCombo = combo
+	if combo != "" {
+		if err := setupKeyboardShortcut(); err != nil {
+			state.keyCombo = oldCombo
+			state.keyCode, state.keyMods = 0, 0
+			if oldCombo != "" && setupKeyboardShortcut() != nil {
+				state.keyCombo = ""
+				state.keyCode, state.keyMods = 0, 0
+			}
+			return err
+		}
+	}
+
+	if state.shortcut != nil {
+		state.shortcut.SetTitle(fmt.Sprintf("Shortcut: %s", state.keyCombo))
+		if state.keyCombo == "" {
+			state.shortcut.Hide()
+		} else {
+			state.shortcut.Show()
+		}
+	}
+
+	slog.Info("Keyboard shortcut rebound", "event", "rebind", "old_hotkey", oldCombo, "hotkey", state.keyCombo)
+	return nil
+}
+
+// --------------------------------- event loop ---------------------------------
+
+// xEvent is what the reader goroutine hands over to the dispatcher.
+type xEvent struct {
+	ev  xgb.Event
+	err xgb.Error
+}
+
+// readXEvents is the only caller of WaitForEvent. It stops when the
+// connection is closed, whether by shutdown or because the server went away,
+// and closes events so the dispatcher can tell the two apart.
+func readXEvents(conn *xgb.Conn, events chan<- xEvent, done chan<- struct{}) {
+	defer close(done)
+	defer close(events)
+
+	for {
+		ev, err := conn.WaitForEvent()
+		if ev == nil && err == nil {
+			// Closed by shutdown, or by xgb itself after a read error.
+			state.mutex.Lock()
+			state.connClosed = true
+			state.mutex.Unlock()
+			return
+		}
+
+		// Timestamps are handed over here rather than by the dispatcher, so
+		// commands running on the dispatcher can wait for one.
+		if e, ok := ev.(xproto.PropertyNotifyEvent); ok && e.Window == state.timeWin {
+			select {
+			case state.timeCh <- e.Time:
+			default:
+			}
+		}
+
+		select {
+		case events <- xEvent{ev, err}:
+		case <-state.ctx.Done():
+			return
+		}
+	}
+}
+
+const (
+	reconnectMinDelay = 500 * time.Millisecond
+	reconnectMaxDelay = 30 * time.Second
+)
+
+// runDispatcher owns all changes to the target window: X events, commands
+// from the tray menu and the control socket, and signals are handled one at
+// a time here until shutdown. When the X connection is lost, it keeps
+// serving them while reconnecting with exponential backoff.
+func (s *AppState) runDispatcher(events <-chan xEvent, signals <-chan os.Signal) {
+	var retry <-chan time.Time
+	backoff := reconnectMinDelay
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case sig := <-signals:
+			slog.Info("Signal received, exiting", "event", "signal", "signal", sig.String())
+			s.requestShutdown()
+			return
+		case cmd := <-s.commands:
+			cmd()
+		case e, ok := <-events:
+			if !ok {
+				if s.ctx.Err() != nil {
+					return
+				}
+				slog.Error("X connection lost, reconnecting", "event", "connection-lost", "retry_in", backoff)
+				s.setConnected(false)
+				events = nil
+				backoff = reconnectMinDelay
+				retry = time.After(backoff)
+				continue
+			}
+			// Events still buffered from a connection xgb has closed would
+			// only lead to requests on it.
+			if !s.isConnected() {
+				continue
+			}
+			guardConn(func() error {
+				s.handleXEvent(e)
+				return nil
+			})
+		case <-retry:
+			newEvents, err := s.reconnect()
+			if err != nil {
+				backoff *= 2
+				if backoff > reconnectMaxDelay {
+					backoff = reconnectMaxDelay
+				}
+				slog.Warn("Reconnect failed", "event", "reconnect", "error", err, "retry_in", backoff)
+				retry = time.After(backoff)
+				continue
+			}
+			slog.Info("Reconnected to X server", "event", "reconnect", windowAttr(s.targetWin))
+			events, retry = newEvents, nil
+			s.setConnected(true)
+		}
+	}
+}
+
+// isConnected reports whether requests can be made on the X connection. xgb
+// closes a lost connection before the dispatcher learns about it, so that is
+// tracked apart from connected.
+func (s *AppState) isConnected() bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.connected && !s.connClosed
+}
+
+func (s *AppState) setConnected(connected bool) {
+	s.mutex.Lock()
+	s.connected = connected
+	s.mutex.Unlock()
+	s.updateSystrayTooltip()
+}
+
+// initConnection sets up everything that lives on the X connection and
+// starts its event reader. It runs at startup and after every reconnect.
+func (s *AppState) initConnection() <-chan xEvent {
+	s.xinerama = false
+	if s.dropdown {
+		if err := xinerama.Init(s.conn); err != nil {
+			slog.Warn("Xinerama not available, using the whole screen", "error", err)
+		} else {
+			s.xinerama = true
+		}
+	}
+
+	if err := setupFocusTracking(s.conn); err != nil {
+		slog.Warn("Failed to set up focus tracking", "error", err)
+	}
+
+	s.keyCode, s.keyMods = 0, 0
+	if s.keyCombo != "" {
+		if err := setupKeyboardShortcut(); err != nil {
+			slog.Warn("Failed to set up keyboard shortcut", "hotkey", s.keyCombo, "error", err)
+		} else {
+			slog.Info("Keyboard shortcut registered", "hotkey", s.keyCombo)
+		}
+	}
+
+	events := make(chan xEvent, 64)
+	s.readerDone = make(chan struct{})
+	go readXEvents(s.conn, events, s.readerDone)
+	return events
+}
+
+// reconnect opens a new X connection and re-resolves the target window on
+// it. Window IDs do not survive a server restart, so a target given by title
+// or class is looked up again. One given by -id is dropped: the server may
+// have handed out the same ID to an unrelated window by now.
+func (s *AppState) reconnect() (<-chan xEvent, error) {
+	conn, err := s.dial()
+	if err != nil {
+		return nil, err
+	}
+	s.conn = conn
+
+	s.mutex.Lock()
+	s.connClosed = false
+	s.placement = windowPlacement{}
+	s.prevActive = 0
+	s.mutex.Unlock()
+
+	events := s.initConnection()
+
+	if s.targetMatch().isEmpty() && s.winID != "" && !s.idStale {
+		slog.Warn("Window IDs do not survive a reconnect, no longer controlling the -id window", "id", s.winID)
+		s.idStale = true
+	}
+	s.targetWin = 0
+	s.updateTargetWindow()
+	if visible, ok := s.currentVisibility(); ok {
+		s.mutex.Lock()
+		s.isVisible = visible
+		s.mutex.Unlock()
+	}
+	return events, nil
+}
+
+func (s *AppState) handleXEvent(e xEvent) {
+	if e.err != nil {
+		slog.Warn("X error", "error", e.err)
+		return
+	}
+
+	switch ev := e.ev.(type) {
+	case xproto.KeyPressEvent:
+		s.mutex.Lock()
+		isShortcut := ev.Detail == s.keyCode && ev.State == s.keyMods
+		s.mutex.Unlock()
+		if isShortcut {
+			slog.Info("Shortcut detected, toggling window visibility", "event", "hotkey", "hotkey", s.keyCombo)
+			setUserTime(ev.Time)
+			toggleWindowVisibility()
+		}
+	case xproto.FocusOutEvent:
+		handleFocusOut(ev)
+	case xproto.PropertyNotifyEvent:
+		handlePropertyNotify(ev)
+	}
+}
+
+var errShuttingDown = errors.New("shutting down")
+
+var errNotConnected = errors.New("not connected to the X server")
+
+// guardConn runs fn, which makes requests on the X connection. When the
+// server goes away, xgb closes the connection by itself and a request made
+// on it panics on a closed channel; that is turned into errNotConnected.
+func guardConn(fn func() error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			if !isClosedChannelPanic(r) {
+				panic(r)
+			}
+			err = errNotConnected
+		}
+	}()
+	return fn()
+}
+
+// isClosedChannelPanic tells whether a recovered panic comes from using a
+// closed channel, which is how xgb fails on a closed connection.
+func isClosedChannelPanic(r interface{}) bool {
+	err, ok := r.(runtime.Error)
+	return ok && strings.Contains(err.Error(), "closed channel")
+}
+
+// runOnDispatcher runs fn on the dispatcher goroutine and waits for it.
+// Requests on a lost connection would panic inside xgb, so fn is not run
+// while reconnecting.
+func (s *AppState) runOnDispatcher(fn func() error) error {
+	done := make(chan error, 1)
+	cmd := func() {
+		if !s.isConnected() {
+			done <- errNotConnected
+			return
+		}
+		done <- guardConn(fn)
+	}
+
+	select {
+	case s.commands <- cmd:
+	case <-s.ctx.Done():
+		return errShuttingDown
+	}
+
+	select {
+	case err := <-done:
+		return err
+	case <-s.ctx.Done():
+		return errShuttingDown
+	}
+}
+
+// --------------------------------- control ---------------------------------
+
+// The control socket speaks JSON-RPC 2.0, one request or response per line.
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+const (
+	rpcParseError     = -32700
+	rpcInvalidRequest = -32600
+	rpcMethodNotFound = -32601
+	rpcInvalidParams  = -32602
+	rpcInternalError  = -32603
+)
+
+type trayStatus struct {
+	Window   string `json:"window"`
+	Title    string `json:"title,omitempty"`
+	Class    string `json:"class,omitempty"`
+	Visible  bool   `json:"visible"`
+	Hotkey   string `json:"hotkey,omitempty"`
+	Dropdown bool   `json:"dropdown"`
+}
+
+type rebindParams struct {
+	Key string `json:"key"`
+}
+
+func startControlSocket(path string) error {
+	if err := control.MakeSocketDir(path); err != nil {
+		return err
+	}
+	// We hold the instance lock, so a socket file still present was left
+	// behind by an instance that did not exit cleanly.
+	os.Remove(path)
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return err
+	}
+	state.listener = listener
+	state.socketPath = path
+
+	state.wg.Add(1)
+	go func() {
+		defer state.wg.Done()
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				select {
+				case <-state.ctx.Done():
+				default:
+					slog.Error("Error accepting control connection", "error", err)
+				}
+				return
+			}
+			go serveControlConn(conn)
+		}
+	}()
+
+	slog.Info("Control socket listening", "socket", path)
+	return nil
+}
+
+func stopControlSocket() {
+	if state.listener != nil {
+		state.listener.Close()
+		os.Remove(state.socketPath)
+	}
+}
+
+func serveControlConn(conn net.Conn) {
+	defer conn.Close()
+
+	scanner := bufio.NewScanner(conn)
+	encoder := json.NewEncoder(conn)
+	for scanner.Scan() {
+		resp := rpcResponse{JSONRPC: "2.0"}
+
+		var req rpcRequest
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			resp.Error = &rpcError{rpcParseError, err.Error()}
+		} else {
+			resp.ID = req.ID
+			resp.Result, resp.Error = handleControlRequest(req)
+		}
+
+		if err := encoder.Encode(resp); err != nil {
+			slog.Warn("Error writing control response", "error", err)
+			return
+		}
+
+		if resp.Error == nil && req.Method == "quit" {
+			state.requestShutdown()
+			return
+		}
+	}
+}
+
+func handleControlRequest(req rpcRequest) (interface{}, *rpcError) {
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		return nil, &rpcError{rpcInvalidRequest, "invalid JSON-RPC 2.0 request"}
+	}
+	slog.Info("Control request", "event", "control", "method", req.Method)
+
+	var action func() error
+	switch req.Method {
+	case "toggle":
+		action = func() error {
+			setUserTime(serverTime(state.conn))
+			return toggleWindowVisibility()
+		}
+	case "show":
+		action = func() error {
+			setUserTime(serverTime(state.conn))
+			return changeWindowVisibility(true)
+		}
+	case "hide":
+		action = func() error {
+			return changeWindowVisibility(false)
+		}
+	case "status":
+		action = func() error { return nil }
+	case "rebind":
+		var params rebindParams
+		if len(req.Params) == 0 || json.Unmarshal(req.Params, &params) != nil {
+			return nil, &rpcError{rpcInvalidParams, "expected params {\"key\": \"ctrl+alt+a\"}"}
+		}
+		action = func() error {
+			return rebindShortcut(params.Key)
+		}
+	case "reload":
+		action = func() error {
+			state.updateTargetWindow()
+			if visible, ok := state.currentVisibility(); ok {
+				state.mutex.Lock()
+				state.isVisible = visible
+				state.mutex.Unlock()
+			}
+			state.updateSystrayTooltip()
+			return nil
+		}
+	case "quit":
+		return "bye", nil
+	default:
+		return nil, &rpcError{rpcMethodNotFound, fmt.Sprintf("unknown method '%s'", req.Method)}
+	}
+
+	var status trayStatus
+	err := state.runOnDispatcher(func() error {
+		if err := action(); err != nil {
+			return err
+		}
+		status = currentStatus()
+		return nil
+	})
+	if err != nil {
+		return nil, &rpcError{rpcInternalError, err.Error()}
+	}
+	return status, nil
+}
+
+func currentStatus() trayStatus {
+	visible, _ := state.currentVisibility()
+
+	state.mutex.Lock()
+	defer state.mutex.Unlock()
+
+	return trayStatus{
+		Window:   fmt.Sprintf("0x%x", state.targetWin),
+		Title:    state.winTitle,
+		Class:    state.winClass,
+		Visible:  visible,
+		Hotkey:   state.keyCombo,
+		Dropdown: state.dropdown,
+	}
+}
+
+// --------------------------------- instance ---------------------------------
+var errInstanceRunning = errors.New("another instance is running")
+
+// acquireInstanceLock takes an exclusive lock next to the control socket,
+// retrying for up to wait. The lock is released by the kernel when the
+// process exits, so a crashed instance never blocks a new one.
+func acquireInstanceLock(socketPath string, wait time.Duration) (*os.File, error) {
+	if err := control.MakeSocketDir(socketPath); err != nil {
+		return nil, err
+	}
+
+	f, err := os.OpenFile(socketPath+".lock", os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(wait)
+	for {
+		err = syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+		if err == nil {
+			return f, nil
+		}
+		if err != syscall.EWOULDBLOCK {
+			f.Close()
+			return nil, err
+		}
+		if !time.Now().Before(deadline) {
+			f.Close()
+			return nil, errInstanceRunning
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// callInstance sends a single request to the instance listening on socketPath.
+func callInstance(socketPath string, method string) (json.RawMessage, error) {
+	conn, err := net.DialTimeout("unix", socketPath, 2*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(15 * time.Second))
+
+	req := rpcRequest{JSONRPC: "2.0", ID: json.RawMessage("1"), Method: method}
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return nil, err
+	}
+
+	line, err := bufio.NewReader(conn).ReadBytes('\n')
+	if err != nil {
+		return nil, err
+	}
+
+	var resp struct {
+		Result json.RawMessage `json:"result"`
+		Error  *rpcError       `json:"error"`
+	}
+	if err := json.Unmarshal(line, &resp); err != nil {
+		return nil, err
+	}
+	if resp.Error != nil {
+		return nil, errors.New(resp.Error.Message)
+	}
+	return resp.Result, nil
+}
+
+// claimInstance makes this process the only tray for its target. If another
+// instance holds the lock, it either hands our request over to it and
+// reports handedOff, or, with -replace, asks it to quit and takes over.
+func claimInstance() (handedOff bool, err error) {
+	state.lockFile, err = acquireInstanceLock(state.socketPath, 0)
+	if err != errInstanceRunning {
+		return false, err
+	}
+
+	if !state.replace {
+		if state.forward == "" {
+			return false, fmt.Errorf("%w for %s", errInstanceRunning, state.formatWindowDescription())
+		}
+		result, err := callInstance(state.socketPath, state.forward)
+		if err != nil {
+			return false, fmt.Errorf("failed to forward '%s' to the running instance: %v", state.forward, err)
+		}
+		slog.Info("Forwarded request to the running instance", "method", state.forward, "result", string(result))
+		return true, nil
+	}
+
+	slog.Info("Asking the running instance to quit", "socket", state.socketPath)
+	if _, err := callInstance(state.socketPath, "quit"); err != nil {
+		slog.Warn("Failed to ask the running instance to quit", "error", err)
+	}
+	state.lockFile, err = acquireInstanceLock(state.socketPath, 5*time.Second)
+	return false, err
+}
+
+// --------------------------------- main ---------------------------------
+func main() {
+	initAppState()
+
+	var useActive, useUnderCursor bool
+	flag.StringVar(&state.winTitle, "title", "", "Window title to control")
+	flag.StringVar(&state.winID, "id", "", "Window ID to control (decimal or hex with 0x prefix)")
+	flag.StringVar(&state.winClass, "class", "", "Window class (WM_CLASS instance or class name) to control")
+	flag.BoolVar(&useActive, "active", false, "Control the window that is active at startup")
+	flag.BoolVar(&useUnderCursor, "under-cursor", false, "Control the window under the mouse pointer at startup")
+	flag.StringVar(&state.keyCombo, "key", "", "Keyboard shortcut (e.g., 'ctrl+shift+alt+a')")
+	flag.StringVar(&state.execCmd, "exec", "", "Command to launch when the window does not exist")
+	flag.DurationVar(&state.execTimeout, "exec-timeout", 10*time.Second, "How long to wait for the launched window to appear")
+	flag.BoolVar(&state.dropdown, "dropdown", false, "Quake-style drop-down mode: show the window docked to a monitor edge")
+	flag.StringVar(&state.edge, "edge", "top", "Drop-down edge: top, bottom, left or right")
+	flag.IntVar(&state.widthPct, "width", 100, "Drop-down width in percent of the monitor")
+	flag.IntVar(&state.heightPct, "height", 40, "Drop-down height in percent of the monitor")
+	flag.DurationVar(&state.animate, "animate", 0, "Drop-down slide animation duration (e.g. '150ms', 0 to disable)")
+	flag.BoolVar(&state.autoHide, "autohide", false, "Hide the drop-down window when it loses focus")
+	flag.BoolVar(&state.summon, "summon", false, "Show the window on the current desktop instead of the one it was hidden on")
+	flag.StringVar(&state.hideMode, "hide-mode", hideModeUnmap, "How to hide the window: unmap, iconify, offscreen, hidden or opacity")
+	flag.DurationVar(&state.fade, "fade", 0, "Fade the window in and out over this duration (e.g. '150ms', 0 to disable; needs a compositing manager)")
+	flag.StringVar(&state.socketPath, "socket", "", "Control socket path (default: derived from the target in $XDG_RUNTIME_DIR/gwctl)")
+	flag.BoolVar(&state.replace, "replace", false, "Replace an instance already running for the same target")
+	flag.StringVar(&state.forward, "forward", "toggle", "Request to forward to an instance already running for the same target (empty to just fail)")
+	flag.StringVar(&state.logLevel, "log-level", "info", "Log level: debug, info, warn or error")
+	flag.StringVar(&state.logFormat, "log-format", "text", "Log format: text or json")
+	flag.StringVar(&state.logFile, "log-file", "", "Append logs to this file instead of stdout")
+	flag.Parse()
+
+	if err := setupLogging(state.logLevel, state.logFormat, state.logFile); err != nil {
+		fmt.Println("Error:", err)
+		os.Exit(2)
+	}
+
+	switch state.edge {
+	case "top", "bottom", "left", "right":
+	default:
+		fmt.Printf("Error: invalid -edge '%s', expected top, bottom, left or right\n", state.edge)
+		os.Exit(2)
+	}
+	if state.widthPct < 1 || state.widthPct > 100 || state.heightPct < 1 || state.heightPct > 100 {
+		fmt.Println("Error: -width and -height must be between 1 and 100")
+		os.Exit(2)
+	}
+	if !isValidHideMode(state.hideMode) {
+		fmt.Printf("Error: invalid -hide-mode '%s', expected unmap, iconify, offscreen, hidden or opacity\n", state.hideMode)
+		os.Exit(2)
+	}
+	if state.autoHide && !state.dropdown {
+		fmt.Println("Error: -autohide requires -dropdown")
+		os.Exit(2)
+	}
+
+	if err := checkWindowFlags(useActive, useUnderCursor); err != nil {
+		fmt.Println("Error:", err)
+		os.Exit(2)
+	}
+	if useActive || useUnderCursor {
+		id, err := resolveWindowFlag(useActive, useUnderCursor)
+		if err != nil {
+			fmt.Println("Error finding window:", err)
+			os.Exit(1)
+		}
+		state.winID = id
+	}
+
+	if state.winTitle == "" && state.winID == "" && state.winClass == "" {
+		fmt.Println("Error: Either -title, -class, -id, -active or -under-cursor must be specified")
+		fmt.Println("Usage: ")
+		fmt.Println("  To control by title: go run main.go -title \"Firefox\" [-key \"ctrl+shift+alt+a\"]")
+		fmt.Println("  To control by class: go run main.go -class \"kitty\" [-key \"ctrl+shift+alt+a\"]")
+		fmt.Println("  To control by ID:    go run main.go -id 0x1234567 [-key \"ctrl+shift+alt+a\"]")
+		fmt.Println("  To launch if absent: go run main.go -class \"kitty\" -exec \"kitty\" [-key \"ctrl+shift+alt+a\"]")
+		return
+	}
+
+	if state.socketPath == "" {
+		state.socketPath = control.SocketPath(state.winTitle, state.winClass, state.winID)
+	}
+
+	handedOff, err := claimInstance()
+	if err != nil {
+		slog.Error("Cannot start", "error", err)
+		os.Exit(1)
+	}
+	if handedOff {
+		return
+	}
+	defer state.lockFile.Close()
+
+	state.conn, err = xgb.NewConn()
+	if err != nil {
+		slog.Error("Cannot open display", "error", err)
+		os.Exit(1)
+	}
+
+	if !state.targetMatch().isEmpty() {
+		state.targetWin, err = findWindowByMatch(state.conn, state.targetMatch())
 	} else {
 		state.targetWin, err = findWindowByID(state.conn, state.winID)
 	}
 
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Window not found: %s\n", state.winTitle+state.winID)
+	if err != nil && state.execCmd != "" {
+		slog.Info("Window not found, will be launched on first toggle", "command", state.execCmd)
+		state.targetWin = 0
+	} else if err != nil {
+		fmt.Fprintf(os.Stderr, "Window not found: %s\n", state.winTitle+state.winID+state.winClass)
 		fmt.Println("\nTip: The window might be:")
 		fmt.Println("1. Not currently open")
 		fmt.Println("2. Using a different title than expected")
 		fmt.Println("3. Not accessible to this program")
 		listWindows(state.conn)
+		state.conn.Close()
 		return
 	}
 
-	attrs, err := xproto.GetWindowAttributes(state.conn, state.targetWin).Reply()
-	if err == nil {
-		state.isVisible = attrs.MapState != 0
+	state.connected = true
+	events := state.initConnection()
+
+	if state.targetWin != 0 {
+		state.isVisible, _ = state.currentVisibility()
 	}
 
-	if state.keyCombo != "" {
-		err = setupKeyboardShortcut()
-		if err != nil {
-			log.Printf("Warning: Failed to set up keyboard shortcut: %v\n", err)
-		} else {
-			log.Printf("Keyboard shortcut '%s' registered\n", state.keyCombo)
-			go listenForKeyEvents()
-		}
+	if err := startControlSocket(state.socketPath); err != nil {
+		slog.Warn("Failed to start control socket", "socket", state.socketPath, "error", err)
 	}
 
-	c := make(chan os.Signal, 1)
-	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	state.wg.Add(1)
 	go func() {
-		<-c
-		log.Println("Interrupt received, exiting...")
-		cleanupAndExit()
+		defer state.wg.Done()
+		state.runDispatcher(events, signals)
 	}()
 
-	log.Printf("Starting system tray for window %s...\n", formatWindowDescription())
+	slog.Info("Starting system tray", windowAttr(state.targetWin), "title", state.winTitle, "class", state.winClass)
 	systray.Run(onSystrayReady, onSystrayExit)
+	state.shutdown()
 }
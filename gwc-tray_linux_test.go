@@ -0,0 +1,182 @@
+//go:build linux
+// +build linux
+
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/BurntSushi/xgb"
+)
+
+var errNoServer = errors.New("no X server in tests")
+
+// newTestState returns a connected state that counts tray quits instead of
+// quitting the tray, and that never reaches a real X server when it tries
+// to reconnect.
+func newTestState() (*AppState, *int32) {
+	s := &AppState{commands: make(chan func()), connected: true}
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+	quits := new(int32)
+	s.quit = func() { atomic.AddInt32(quits, 1) }
+	s.dial = func() (*xgb.Conn, error) { return nil, errNoServer }
+	return s, quits
+}
+
+// startDispatcher runs the dispatcher and returns a channel closed once it
+// has returned.
+func startDispatcher(s *AppState, events <-chan xEvent, signals <-chan os.Signal) <-chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.runDispatcher(events, signals)
+	}()
+	return done
+}
+
+func waitFor(t *testing.T, done <-chan struct{}, what string) {
+	t.Helper()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for %s", what)
+	}
+}
+
+func TestRequestShutdownTwice(t *testing.T) {
+	s, quits := newTestState()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.requestShutdown()
+		}()
+	}
+	wg.Wait()
+
+	if n := atomic.LoadInt32(quits); n != 1 {
+		t.Errorf("tray quit %d times, want 1", n)
+	}
+	if s.ctx.Err() == nil {
+		t.Error("context not cancelled")
+	}
+}
+
+func TestSignalAndMenuQuit(t *testing.T) {
+	s, quits := newTestState()
+	signals := make(chan os.Signal, 1)
+	done := startDispatcher(s, make(chan xEvent), signals)
+
+	signals <- syscall.SIGTERM
+	s.requestShutdown()
+	waitFor(t, done, "the dispatcher to return")
+
+	if n := atomic.LoadInt32(quits); n != 1 {
+		t.Errorf("tray quit %d times, want 1", n)
+	}
+	if err := s.runOnDispatcher(func() error { return nil }); err != errShuttingDown {
+		t.Errorf("command after quit returned %v, want %v", err, errShuttingDown)
+	}
+}
+
+func TestEventsClosedWhileCommandRuns(t *testing.T) {
+	s, _ := newTestState()
+	events := make(chan xEvent, 1)
+	done := startDispatcher(s, events, nil)
+	defer func() {
+		s.requestShutdown()
+		waitFor(t, done, "the dispatcher to return")
+	}()
+
+	started, release := make(chan struct{}), make(chan struct{})
+	result := make(chan error, 1)
+	go func() {
+		result <- s.runOnDispatcher(func() error {
+			close(started)
+			<-release
+			return nil
+		})
+	}()
+
+	waitFor(t, started, "the command to start")
+	close(events)
+	close(release)
+	if err := <-result; err != nil {
+		t.Fatalf("running command returned %v", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for s.isConnected() {
+		if time.Now().After(deadline) {
+			t.Fatal("dispatcher did not notice the closed event channel")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	ran := false
+	err := s.runOnDispatcher(func() error {
+		ran = true
+		return nil
+	})
+	if err != errNotConnected || ran {
+		t.Errorf("command while disconnected: ran %v, returned %v, want %v", ran, err, errNotConnected)
+	}
+}
+
+func TestReconnectUsesDialer(t *testing.T) {
+	s, _ := newTestState()
+	dialed := make(chan struct{}, 1)
+	s.dial = func() (*xgb.Conn, error) {
+		select {
+		case dialed <- struct{}{}:
+		default:
+		}
+		return nil, errNoServer
+	}
+	events := make(chan xEvent)
+	done := startDispatcher(s, events, nil)
+	defer func() {
+		s.requestShutdown()
+		waitFor(t, done, "the dispatcher to return")
+	}()
+
+	close(events)
+	waitFor(t, dialed, "a reconnect attempt")
+	if s.isConnected() {
+		t.Error("connected although dialing failed")
+	}
+}
+
+func TestRequestOnClosedConnection(t *testing.T) {
+	s, _ := newTestState()
+	done := startDispatcher(s, make(chan xEvent), nil)
+	defer func() {
+		s.requestShutdown()
+		waitFor(t, done, "the dispatcher to return")
+	}()
+
+	// What a request on a connection xgb has closed comes down to.
+	requests := make(chan struct{})
+	close(requests)
+	err := s.runOnDispatcher(func() error {
+		requests <- struct{}{}
+		return nil
+	})
+	if err != errNotConnected {
+		t.Errorf("request on a closed connection returned %v, want %v", err, errNotConnected)
+	}
+
+	want := errors.New("still dispatching")
+	if err := s.runOnDispatcher(func() error { return want }); err != want {
+		t.Errorf("next command returned %v, want %v", err, want)
+	}
+}
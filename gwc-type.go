@@ -0,0 +1,177 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+	"unicode/utf16"
+	"unsafe"
+
+	"gwctl/internal/target"
+)
+
+var (
+	modUser32               = syscall.NewLazyDLL("user32.dll")
+	procFindWindow          = modUser32.NewProc("FindWindowW")
+	procSetForegroundWindow = modUser32.NewProc("SetForegroundWindow")
+	procGetForegroundWindow = modUser32.NewProc("GetForegroundWindow")
+	procSendInput           = modUser32.NewProc("SendInput")
+	procPostMessage         = modUser32.NewProc("PostMessageW")
+	procMapVirtualKey       = modUser32.NewProc("MapVirtualKeyW")
+	INPUT_KEYBOARD          = 1
+	KEYEVENTF_KEYUP         = 0x0002
+	KEYEVENTF_UNICODE       = 0x0004
+	WM_KEYDOWN              = 0x0100
+	WM_KEYUP                = 0x0101
+	WM_CHAR                 = 0x0102
+	MAPVK_VK_TO_VSC         = 0
+	VK_RETURN               = 0x0D
+)
+
+// keyboardInput is the INPUT structure with its KEYBDINPUT member. The
+// padding makes it as large as the union's largest member, MOUSEINPUT.
+type keyboardInput struct {
+	typ uint32
+	ki  struct {
+		vk        uint16
+		scan      uint16
+		flags     uint32
+		time      uint32
+		extraInfo uintptr
+	}
+	_ [8]byte
+}
+
+func findWindow(windowName *uint16) (syscall.Handle, error) {
+	ret, _, err := procFindWindow.Call(uintptr(unsafe.Pointer(nil)), uintptr(unsafe.Pointer(windowName)))
+	if ret == 0 {
+		return 0, err
+	}
+	return syscall.Handle(ret), nil
+}
+
+// focusWindow brings the window to the foreground so that SendInput
+// reaches it, and waits up to timeout for that to happen.
+func focusWindow(hwnd syscall.Handle, timeout time.Duration) error {
+	procSetForegroundWindow.Call(uintptr(hwnd))
+
+	deadline := time.Now().Add(timeout)
+	for {
+		if fg, _, _ := procGetForegroundWindow.Call(); syscall.Handle(fg) == hwnd {
+			return nil
+		}
+		if !time.Now().Before(deadline) {
+			return fmt.Errorf("window did not come to the foreground, try gwc-focuse first")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func sendInputs(inputs []keyboardInput) error {
+	ret, _, err := procSendInput.Call(uintptr(len(inputs)),
+		uintptr(unsafe.Pointer(&inputs[0])), unsafe.Sizeof(inputs[0]))
+	if int(ret) != len(inputs) {
+		return fmt.Errorf("SendInput was blocked: %v", err)
+	}
+	return nil
+}
+
+func keyInput(vk, scan uint16, flags uint32) keyboardInput {
+	input := keyboardInput{typ: uint32(INPUT_KEYBOARD)}
+	input.ki.vk = vk
+	input.ki.scan = scan
+	input.ki.flags = flags
+	return input
+}
+
+// postKey posts a key press and release straight to the window's message
+// queue, which works without focus but cannot convey held modifiers.
+func postKey(hwnd syscall.Handle, vk uint16) error {
+	scan, _, _ := procMapVirtualKey.Call(uintptr(vk), uintptr(MAPVK_VK_TO_VSC))
+	lParam := 1 | scan<<16
+	ret, _, err := procPostMessage.Call(uintptr(hwnd), uintptr(WM_KEYDOWN), uintptr(vk), lParam)
+	if ret == 0 {
+		return fmt.Errorf("failed to post key: %v", err)
+	}
+	procPostMessage.Call(uintptr(hwnd), uintptr(WM_KEYUP), uintptr(vk), lParam|0xC0000000)
+	return nil
+}
+
+// charInputs types one UTF-16 code unit. Line breaks are sent as the
+// Enter key, which applications handle more consistently.
+func charInputs(unit uint16) []keyboardInput {
+	if unit == '\n' {
+		return []keyboardInput{
+			keyInput(uint16(VK_RETURN), 0, 0),
+			keyInput(uint16(VK_RETURN), 0, uint32(KEYEVENTF_KEYUP)),
+		}
+	}
+	return []keyboardInput{
+		keyInput(0, unit, uint32(KEYEVENTF_UNICODE)),
+		keyInput(0, unit, uint32(KEYEVENTF_UNICODE|KEYEVENTF_KEYUP)),
+	}
+}
+
+func postChar(hwnd syscall.Handle, unit uint16) error {
+	if unit == '\n' {
+		return postKey(hwnd, uint16(VK_RETURN))
+	}
+	ret, _, err := procPostMessage.Call(uintptr(hwnd), uintptr(WM_CHAR), uintptr(unit), 1)
+	if ret == 0 {
+		return fmt.Errorf("failed to post character: %v", err)
+	}
+	return nil
+}
+
+func main() {
+	var windowTitle, method string
+	var targetFlags target.Flags
+	var delay, timeout time.Duration
+	var noFocus bool
+	flag.StringVar(&windowTitle, "title", "", "Window title to find and type into")
+	targetFlags.Register()
+	flag.StringVar(&method, "method", "sendinput", "How to send the text: sendinput (to the foreground window) or postmessage")
+	flag.DurationVar(&delay, "delay", 12*time.Millisecond, "Delay after each character")
+	flag.BoolVar(&noFocus, "no-focus", false, "Do not focus the window before typing with sendinput")
+	flag.DurationVar(&timeout, "timeout", time.Second, "How long to wait for the window to come to the foreground")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "Usage: gwc-type -title TITLE TEXT...")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() == 0 || method != "sendinput" && method != "postmessage" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	text := strings.Join(flag.Args(), " ")
+
+	hwnd, err := target.Window(windowTitle, targetFlags, findWindow)
+	if err != nil {
+		fmt.Println("Error finding window:", err)
+		os.Exit(1)
+	}
+
+	if method == "sendinput" && !noFocus {
+		if err := focusWindow(hwnd, timeout); err != nil {
+			fmt.Println("Error focusing window:", err)
+			os.Exit(1)
+		}
+	}
+
+	for _, unit := range utf16.Encode([]rune(text)) {
+		if method == "postmessage" {
+			err = postChar(hwnd, unit)
+		} else {
+			err = sendInputs(charInputs(unit))
+		}
+		if err != nil {
+			fmt.Println("Error typing text:", err)
+			os.Exit(1)
+		}
+		time.Sleep(delay)
+	}
+}
@@ -0,0 +1,76 @@
+//go:build linux
+// +build linux
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/xgb"
+	"gwctl/internal/keyboard"
+	"gwctl/internal/target"
+	"gwctl/internal/x11"
+)
+
+func main() {
+	var windowTitle, method string
+	var targetFlags target.Flags
+	var delay, timeout time.Duration
+	var noFocus bool
+	flag.StringVar(&windowTitle, "title", "", "Window title to find and type into")
+	targetFlags.Register()
+	flag.StringVar(&method, "method", "xtest", "How to send the keys: xtest (to the focused window) or sendevent")
+	flag.DurationVar(&delay, "delay", 12*time.Millisecond, "Delay after each character")
+	flag.BoolVar(&noFocus, "no-focus", false, "Do not focus the window before typing with xtest")
+	flag.DurationVar(&timeout, "timeout", time.Second, "How long to wait for the window to get the focus")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "Usage: gwc-type -title TITLE TEXT...")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() == 0 || method != "xtest" && method != "sendevent" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	text := strings.Join(flag.Args(), " ")
+
+	conn, err := xgb.NewConn()
+	if err != nil {
+		fmt.Println("Error opening display:", err)
+		os.Exit(1)
+	}
+	defer conn.Close()
+
+	window, err := target.Window(conn, windowTitle, targetFlags, x11.FindWindow)
+	if err != nil {
+		fmt.Println("Error finding window:", err)
+		os.Exit(1)
+	}
+
+	if method == "xtest" && !noFocus {
+		if !x11.HasFocus(conn, window) && !x11.ActivateWindow(conn, window, timeout) {
+			fmt.Println("Error focusing window: window did not get the focus, try gwc-focuse first")
+			os.Exit(1)
+		}
+	}
+
+	kb, err := keyboard.New(conn, window, method == "xtest", delay)
+	if err != nil {
+		fmt.Println("Error:", err)
+		os.Exit(1)
+	}
+	defer kb.Close()
+
+	for _, r := range text {
+		if err := kb.Tap(keyboard.Combo{Keysym: keyboard.KeysymForRune(r)}); err != nil {
+			fmt.Println("Error typing text:", err)
+			kb.Close()
+			os.Exit(1)
+		}
+	}
+}
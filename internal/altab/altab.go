@@ -0,0 +1,8 @@
+// Package altab hides windows from, and shows them in, Alt+Tab, for
+// gwc-hide-altab, gwc-show-altab and gwc-altab-status.
+//
+// On Windows this is a matter of the window's extended style, on Linux of
+// the _NET_WM_STATE entries that window managers honour in their switchers.
+// The logic is kept apart from the system calls so it can be tested
+// anywhere.
+package altab
@@ -0,0 +1,44 @@
+package altab
+
+import (
+	"fmt"
+	"time"
+)
+
+// StateBackend reads and changes one window's _NET_WM_STATE. It is the
+// only part of the Linux logic that talks to the X server.
+type StateBackend interface {
+	States() (map[string]bool, error)
+	SetStates(add bool, states ...string) error
+}
+
+// States are the _NET_WM_STATE entries that keep a window out of Alt+Tab,
+// the taskbar and the pager.
+var States = []string{"_NET_WM_STATE_SKIP_TASKBAR", "_NET_WM_STATE_SKIP_PAGER"}
+
+// StatesHidden reports whether a window with the states is hidden from
+// Alt+Tab.
+func StatesHidden(states map[string]bool) bool {
+	return states["_NET_WM_STATE_SKIP_TASKBAR"] || states["_NET_WM_STATE_SKIP_PAGER"]
+}
+
+// SetStatesHidden asks the window manager to change the states and waits
+// for it to apply them, returning whether the window actually ended up
+// hidden from Alt+Tab.
+func SetStatesHidden(backend StateBackend, hidden bool, timeout time.Duration) (bool, error) {
+	if err := backend.SetStates(hidden, States...); err != nil {
+		return false, err
+	}
+
+	deadline := time.Now().Add(timeout)
+	for {
+		states, err := backend.States()
+		if err != nil {
+			return false, fmt.Errorf("failed to verify window state: %v", err)
+		}
+		if StatesHidden(states) == hidden || !time.Now().Before(deadline) {
+			return StatesHidden(states), nil
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
@@ -0,0 +1,28 @@
+//go:build linux
+// +build linux
+
+package altab
+
+import (
+	"github.com/BurntSushi/xgb"
+	"github.com/BurntSushi/xgb/xproto"
+	"gwctl/internal/x11"
+)
+
+// X11Window is the StateBackend for a real window.
+type X11Window struct {
+	Conn   *xgb.Conn
+	Window xproto.Window
+}
+
+func (w X11Window) States() (map[string]bool, error) {
+	return x11.WindowStates(w.Conn, w.Window)
+}
+
+func (w X11Window) SetStates(add bool, states ...string) error {
+	action := uint32(x11.StateRemove)
+	if add {
+		action = x11.StateAdd
+	}
+	return x11.SetWindowStates(w.Conn, w.Window, action, states...)
+}
@@ -0,0 +1,124 @@
+package altab
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// fakeWM keeps a window's _NET_WM_STATE in memory. With ignoreWrites it
+// accepts changes without applying them, like a window manager that does
+// not support the states; with delay it applies them only after that many
+// reads, like one that handles the request asynchronously.
+type fakeWM struct {
+	states       map[string]bool
+	ignoreWrites bool
+	delay        int
+	pending      func()
+	getErr       error
+	setErr       error
+}
+
+func (wm *fakeWM) States() (map[string]bool, error) {
+	if wm.pending != nil {
+		if wm.delay == 0 {
+			wm.pending()
+			wm.pending = nil
+		} else {
+			wm.delay--
+		}
+	}
+	states := make(map[string]bool)
+	for name, set := range wm.states {
+		states[name] = set
+	}
+	return states, wm.getErr
+}
+
+func (wm *fakeWM) SetStates(add bool, states ...string) error {
+	if wm.setErr != nil {
+		return wm.setErr
+	}
+	if wm.ignoreWrites {
+		return nil
+	}
+	wm.pending = func() {
+		for _, name := range states {
+			if add {
+				wm.states[name] = true
+			} else {
+				delete(wm.states, name)
+			}
+		}
+	}
+	return nil
+}
+
+func TestStatesHidden(t *testing.T) {
+	tests := []struct {
+		states map[string]bool
+		want   bool
+	}{
+		{map[string]bool{}, false},
+		{map[string]bool{"_NET_WM_STATE_ABOVE": true}, false},
+		{map[string]bool{"_NET_WM_STATE_SKIP_TASKBAR": true}, true},
+		{map[string]bool{"_NET_WM_STATE_SKIP_PAGER": true}, true},
+		{map[string]bool{"_NET_WM_STATE_SKIP_TASKBAR": true, "_NET_WM_STATE_SKIP_PAGER": true}, true},
+	}
+	for _, tt := range tests {
+		if got := StatesHidden(tt.states); got != tt.want {
+			t.Errorf("StatesHidden(%v) = %t, want %t", tt.states, got, tt.want)
+		}
+	}
+}
+
+func TestSetStatesHidden(t *testing.T) {
+	wm := &fakeWM{states: map[string]bool{"_NET_WM_STATE_ABOVE": true}, delay: 2}
+	hidden, err := SetStatesHidden(wm, true, time.Second)
+	if err != nil || !hidden {
+		t.Fatalf("SetStatesHidden(true) = %t, %v, want true, nil", hidden, err)
+	}
+	for _, name := range []string{"_NET_WM_STATE_ABOVE", "_NET_WM_STATE_SKIP_TASKBAR", "_NET_WM_STATE_SKIP_PAGER"} {
+		if !wm.states[name] {
+			t.Errorf("%s not set", name)
+		}
+	}
+
+	wm.delay = 2
+	hidden, err = SetStatesHidden(wm, false, time.Second)
+	if err != nil || hidden {
+		t.Fatalf("SetStatesHidden(false) = %t, %v, want false, nil", hidden, err)
+	}
+	if !wm.states["_NET_WM_STATE_ABOVE"] || len(wm.states) != 1 {
+		t.Errorf("states = %v, want only _NET_WM_STATE_ABOVE", wm.states)
+	}
+}
+
+func TestSetStatesHiddenIgnoredWrite(t *testing.T) {
+	wm := &fakeWM{states: map[string]bool{}, ignoreWrites: true}
+	start := time.Now()
+	hidden, err := SetStatesHidden(wm, true, 50*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hidden {
+		t.Error("reported hidden although the states never changed")
+	}
+	if time.Since(start) < 50*time.Millisecond {
+		t.Error("gave up before the timeout")
+	}
+}
+
+func TestSetStatesHiddenErrors(t *testing.T) {
+	failed := errors.New("failed")
+
+	wm := &fakeWM{states: map[string]bool{}, setErr: failed}
+	if _, err := SetStatesHidden(wm, true, time.Second); err != failed {
+		t.Errorf("with failing write, err = %v, want %v", err, failed)
+	}
+
+	wm = &fakeWM{states: map[string]bool{}, getErr: failed}
+	if _, err := SetStatesHidden(wm, true, time.Second); err == nil {
+		t.Error("with failing read, no error")
+	}
+}
@@ -0,0 +1,49 @@
+package altab
+
+import "fmt"
+
+const (
+	WS_EX_APPWINDOW  = 0x00040000
+	WS_EX_TOOLWINDOW = 0x00000080
+)
+
+// StyleBackend reads and writes one window's extended style. It is the
+// only part of the Windows logic that talks to the system.
+type StyleBackend interface {
+	ExStyle() (uintptr, error)
+	SetExStyle(style uintptr) error
+}
+
+// Style returns style with the bits set that hide the window from, or show
+// it in, Alt+Tab and the taskbar.
+func Style(style uintptr, hidden bool) uintptr {
+	if hidden {
+		return (style | WS_EX_TOOLWINDOW) &^ WS_EX_APPWINDOW
+	}
+	return (style | WS_EX_APPWINDOW) &^ WS_EX_TOOLWINDOW
+}
+
+// StyleHidden reports whether a window with the style is hidden from
+// Alt+Tab. WS_EX_APPWINDOW wins over WS_EX_TOOLWINDOW.
+func StyleHidden(style uintptr) bool {
+	return style&WS_EX_TOOLWINDOW != 0 && style&WS_EX_APPWINDOW == 0
+}
+
+// SetStyleHidden writes the style and reads it back, returning whether the
+// window actually ended up hidden from Alt+Tab.
+func SetStyleHidden(backend StyleBackend, hidden bool) (bool, error) {
+	style, err := backend.ExStyle()
+	if err != nil {
+		return false, err
+	}
+
+	if err := backend.SetExStyle(Style(style, hidden)); err != nil {
+		return StyleHidden(style), err
+	}
+
+	style, err = backend.ExStyle()
+	if err != nil {
+		return false, fmt.Errorf("failed to verify window style: %v", err)
+	}
+	return StyleHidden(style), nil
+}
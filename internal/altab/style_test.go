@@ -0,0 +1,120 @@
+package altab
+
+import (
+	"errors"
+	"testing"
+)
+
+const WS_EX_TOPMOST = 0x00000008
+
+// fakeWindow keeps a window's extended style in memory. With ignoreWrites
+// it accepts writes without applying them, like a window that resets its
+// own style.
+type fakeWindow struct {
+	style        uintptr
+	ignoreWrites bool
+	getErr       error
+	setErr       error
+}
+
+func (w *fakeWindow) ExStyle() (uintptr, error) {
+	return w.style, w.getErr
+}
+
+func (w *fakeWindow) SetExStyle(style uintptr) error {
+	if w.setErr != nil {
+		return w.setErr
+	}
+	if !w.ignoreWrites {
+		w.style = style
+	}
+	return nil
+}
+
+func TestStyle(t *testing.T) {
+	tests := []struct {
+		style  uintptr
+		hidden bool
+		want   uintptr
+	}{
+		{0, true, WS_EX_TOOLWINDOW},
+		{WS_EX_APPWINDOW, true, WS_EX_TOOLWINDOW},
+		{WS_EX_APPWINDOW | WS_EX_TOPMOST, true, WS_EX_TOOLWINDOW | WS_EX_TOPMOST},
+		{WS_EX_TOOLWINDOW, true, WS_EX_TOOLWINDOW},
+		{0, false, WS_EX_APPWINDOW},
+		{WS_EX_TOOLWINDOW | WS_EX_TOPMOST, false, WS_EX_APPWINDOW | WS_EX_TOPMOST},
+	}
+	for _, tt := range tests {
+		if got := Style(tt.style, tt.hidden); got != tt.want {
+			t.Errorf("Style(%#x, %t) = %#x, want %#x", tt.style, tt.hidden, got, tt.want)
+		}
+	}
+}
+
+func TestStyleHidden(t *testing.T) {
+	tests := []struct {
+		style uintptr
+		want  bool
+	}{
+		{0, false},
+		{WS_EX_APPWINDOW, false},
+		{WS_EX_TOOLWINDOW, true},
+		{WS_EX_TOOLWINDOW | WS_EX_TOPMOST, true},
+		// WS_EX_APPWINDOW wins, the window stays in Alt+Tab.
+		{WS_EX_TOOLWINDOW | WS_EX_APPWINDOW, false},
+	}
+	for _, tt := range tests {
+		if got := StyleHidden(tt.style); got != tt.want {
+			t.Errorf("StyleHidden(%#x) = %t, want %t", tt.style, got, tt.want)
+		}
+	}
+}
+
+func TestSetStyleHidden(t *testing.T) {
+	tests := []struct {
+		style     uintptr
+		hidden    bool
+		wantStyle uintptr
+	}{
+		{WS_EX_APPWINDOW | WS_EX_TOPMOST, true, WS_EX_TOOLWINDOW | WS_EX_TOPMOST},
+		// A window without any extended style is a valid starting point.
+		{0, true, WS_EX_TOOLWINDOW},
+		{WS_EX_TOOLWINDOW, false, WS_EX_APPWINDOW},
+	}
+	for _, tt := range tests {
+		window := &fakeWindow{style: tt.style}
+		hidden, err := SetStyleHidden(window, tt.hidden)
+		if err != nil || hidden != tt.hidden {
+			t.Errorf("SetStyleHidden(%#x, %t) = %t, %v, want %t, nil", tt.style, tt.hidden, hidden, err, tt.hidden)
+		}
+		if window.style != tt.wantStyle {
+			t.Errorf("SetStyleHidden(%#x, %t) left style %#x, want %#x", tt.style, tt.hidden, window.style, tt.wantStyle)
+		}
+	}
+}
+
+func TestSetStyleHiddenIgnoredWrite(t *testing.T) {
+	window := &fakeWindow{style: WS_EX_APPWINDOW, ignoreWrites: true}
+	hidden, err := SetStyleHidden(window, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hidden {
+		t.Error("reported hidden although the style never changed")
+	}
+}
+
+func TestSetStyleHiddenErrors(t *testing.T) {
+	failed := errors.New("failed")
+
+	window := &fakeWindow{getErr: failed}
+	if _, err := SetStyleHidden(window, true); err != failed {
+		t.Errorf("with failing read, err = %v, want %v", err, failed)
+	}
+
+	window = &fakeWindow{style: WS_EX_APPWINDOW, setErr: failed}
+	hidden, err := SetStyleHidden(window, true)
+	if err != failed || hidden {
+		t.Errorf("with failing write = %t, %v, want false, %v", hidden, err, failed)
+	}
+}
@@ -0,0 +1,40 @@
+package altab
+
+import (
+	"fmt"
+	"syscall"
+)
+
+var (
+	modUser32         = syscall.NewLazyDLL("user32.dll")
+	modKernel32       = syscall.NewLazyDLL("kernel32.dll")
+	procGetWindowLong = modUser32.NewProc("GetWindowLongW")
+	procSetWindowLong = modUser32.NewProc("SetWindowLongW")
+	procSetLastError  = modKernel32.NewProc("SetLastError")
+	GWL_EXSTYLE       = -20
+)
+
+// Window is the StyleBackend for a real window.
+type Window syscall.Handle
+
+// ExStyle returns the window's extended style. A style of 0 is valid, so
+// the call only failed if it also left an error code behind.
+func (w Window) ExStyle() (uintptr, error) {
+	procSetLastError.Call(0)
+	style, _, err := procGetWindowLong.Call(uintptr(w), uintptr(GWL_EXSTYLE))
+	if style == 0 && err != syscall.Errno(0) {
+		return 0, fmt.Errorf("failed to get window style: %v", err)
+	}
+	return style, nil
+}
+
+// SetExStyle replaces the window's extended style. SetWindowLongW returns
+// the previous style, which may be 0 too.
+func (w Window) SetExStyle(style uintptr) error {
+	procSetLastError.Call(0)
+	prev, _, err := procSetWindowLong.Call(uintptr(w), uintptr(GWL_EXSTYLE), style)
+	if prev == 0 && err != syscall.Errno(0) {
+		return fmt.Errorf("failed to set window style: %v", err)
+	}
+	return nil
+}
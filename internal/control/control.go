@@ -0,0 +1,95 @@
+//go:build linux
+// +build linux
+
+// Package control locates the control socket of a gwc-tray instance, for
+// gwc-tray and gwc-tray-ctl.
+package control
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+// maxReadable bounds the readable part of a socket name, since socket
+// paths are limited to about 100 bytes.
+const maxReadable = 32
+
+// Dir returns the directory default sockets live in: $XDG_RUNTIME_DIR/gwctl,
+// or a per-user directory in the temporary directory.
+func Dir() string {
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		return filepath.Join(dir, "gwctl")
+	}
+	return filepath.Join(os.TempDir(), fmt.Sprintf("gwctl-%d", os.Getuid()))
+}
+
+// SocketPath derives a per-target socket path, so that one tray instance
+// can run per window. The name keeps a readable form of the target for
+// people listing the directory, and a hash of the exact target so that
+// targets differing only in case or punctuation get different sockets.
+func SocketPath(title, class, id string) string {
+	var name string
+	switch {
+	case title != "":
+		name = "title-" + title
+	case class != "":
+		name = "class-" + class
+	default:
+		name = "id-" + id
+	}
+
+	readable := strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-' {
+			return r
+		}
+		return '_'
+	}, strings.ToLower(name))
+	if len(readable) > maxReadable {
+		readable = readable[:maxReadable]
+	}
+	sum := sha256.Sum256([]byte(name))
+
+	return filepath.Join(Dir(), "tray-"+readable+"-"+hex.EncodeToString(sum[:8])+".sock")
+}
+
+// MakeSocketDir creates the directory for the socket. The default directory
+// may sit in a shared temporary directory under a predictable name, so it
+// is only used if it is a real directory owned by the user and closed to
+// everyone else; anyone else could otherwise take over the socket.
+func MakeSocketDir(socketPath string) error {
+	dir := filepath.Dir(socketPath)
+	if dir != Dir() {
+		return os.MkdirAll(dir, 0700)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dir), 0755); err != nil {
+		return err
+	}
+	if err := os.Mkdir(dir, 0700); err != nil && !os.IsExist(err) {
+		return err
+	}
+	return checkPrivateDir(dir)
+}
+
+func checkPrivateDir(dir string) error {
+	info, err := os.Lstat(dir)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", dir)
+	}
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok || int(stat.Uid) != os.Getuid() {
+		return fmt.Errorf("%s is not owned by the current user", dir)
+	}
+	if info.Mode().Perm() != 0700 {
+		return fmt.Errorf("%s has mode %#o, want 0700", dir, info.Mode().Perm())
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+//go:build linux
+// +build linux
+
+package control
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSocketPathDistinct(t *testing.T) {
+	t.Setenv("XDG_RUNTIME_DIR", "/run/user/1000")
+
+	tests := []struct {
+		title, class, id string
+	}{
+		{"Foo Bar", "", ""},
+		{"foo_bar", "", ""},
+		{"foo bar", "", ""},
+		{"", "foo bar", ""},
+		{"", "", "0x1234"},
+		{strings.Repeat("a", 200), "", ""},
+		{strings.Repeat("a", 201), "", ""},
+	}
+	seen := make(map[string]bool)
+	for _, tt := range tests {
+		path := SocketPath(tt.title, tt.class, tt.id)
+		if seen[path] {
+			t.Errorf("SocketPath(%q, %q, %q) = %s, already used by another target", tt.title, tt.class, tt.id, path)
+		}
+		seen[path] = true
+		if filepath.Dir(path) != "/run/user/1000/gwctl" {
+			t.Errorf("SocketPath(%q, %q, %q) = %s, not in the runtime directory", tt.title, tt.class, tt.id, path)
+		}
+		if len(path) > 100 {
+			t.Errorf("SocketPath(%q, %q, %q) is %d bytes long", tt.title, tt.class, tt.id, len(path))
+		}
+	}
+
+	if SocketPath("Foo Bar", "", "") != SocketPath("Foo Bar", "", "") {
+		t.Error("SocketPath is not stable")
+	}
+}
+
+func TestMakeSocketDir(t *testing.T) {
+	runtime := t.TempDir()
+	t.Setenv("XDG_RUNTIME_DIR", runtime)
+	dir := filepath.Join(runtime, "gwctl")
+	socket := filepath.Join(dir, "tray.sock")
+
+	if err := MakeSocketDir(socket); err != nil {
+		t.Fatalf("creating the directory: %v", err)
+	}
+	if err := MakeSocketDir(socket); err != nil {
+		t.Fatalf("reusing the directory: %v", err)
+	}
+
+	if err := os.Chmod(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := MakeSocketDir(socket); err == nil {
+		t.Error("accepted a directory others can read")
+	}
+
+	os.Remove(dir)
+	other := t.TempDir()
+	if err := os.Symlink(other, dir); err != nil {
+		t.Fatal(err)
+	}
+	if err := MakeSocketDir(socket); err == nil {
+		t.Error("accepted a symlink")
+	}
+
+	// A socket the user placed elsewhere is left to them.
+	custom := filepath.Join(t.TempDir(), "sub", "tray.sock")
+	if err := MakeSocketDir(custom); err != nil {
+		t.Errorf("creating a custom directory: %v", err)
+	}
+}
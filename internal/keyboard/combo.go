@@ -0,0 +1,115 @@
+// Package keyboard parses key combinations and synthesizes key events, for
+// gwc-key, gwc-type and the gwc-tray hotkey.
+package keyboard
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/BurntSushi/xgb/xproto"
+)
+
+// Keysyms of the keys that can be named in a key combination, besides
+// letters and digits.
+var namedKeysyms = map[string]xproto.Keysym{
+	"enter":     0xff0d,
+	"return":    0xff0d,
+	"tab":       0xff09,
+	"escape":    0xff1b,
+	"esc":       0xff1b,
+	"space":     0x0020,
+	"backspace": 0xff08,
+	"delete":    0xffff,
+	"insert":    0xff63,
+	"home":      0xff50,
+	"end":       0xff57,
+	"pageup":    0xff55,
+	"pagedown":  0xff56,
+	"left":      0xff51,
+	"up":        0xff52,
+	"right":     0xff53,
+	"down":      0xff54,
+	"f1":        0xffbe,
+	"f2":        0xffbf,
+	"f3":        0xffc0,
+	"f4":        0xffc1,
+	"f5":        0xffc2,
+	"f6":        0xffc3,
+	"f7":        0xffc4,
+	"f8":        0xffc5,
+	"f9":        0xffc6,
+	"f10":       0xffc7,
+	"f11":       0xffc8,
+	"f12":       0xffc9,
+}
+
+// Modifier is a modifier key: the state bit it sets and the key that
+// produces it.
+type Modifier struct {
+	Mask   uint16
+	Keysym xproto.Keysym
+}
+
+var modifiers = map[string]Modifier{
+	"ctrl":  {xproto.ModMaskControl, 0xffe3},
+	"shift": {xproto.ModMaskShift, 0xffe1},
+	"alt":   {xproto.ModMask1, 0xffe9},
+	"super": {xproto.ModMask4, 0xffeb},
+}
+
+// Combo is a key pressed with modifiers held.
+type Combo struct {
+	Mods   []Modifier
+	Keysym xproto.Keysym
+}
+
+// Mask returns the modifier state the combination is pressed with.
+func (c Combo) Mask() uint16 {
+	var mask uint16
+	for _, mod := range c.Mods {
+		mask |= mod.Mask
+	}
+	return mask
+}
+
+// ParseCombo parses a key combination such as "ctrl+shift+s", "alt+f4" or
+// "enter": any of ctrl, shift, alt and super, then a letter, a digit or a
+// named key.
+func ParseCombo(combo string) (Combo, error) {
+	parts := strings.Split(strings.ToLower(combo), "+")
+	keyName := parts[len(parts)-1]
+
+	var c Combo
+	for _, name := range parts[:len(parts)-1] {
+		mod, ok := modifiers[name]
+		if !ok {
+			return Combo{}, fmt.Errorf("unknown modifier: %s", name)
+		}
+		c.Mods = append(c.Mods, mod)
+	}
+
+	if keysym, ok := namedKeysyms[keyName]; ok {
+		c.Keysym = keysym
+		return c, nil
+	}
+	if len(keyName) == 1 && (keyName[0] >= 'a' && keyName[0] <= 'z' || keyName[0] >= '0' && keyName[0] <= '9') {
+		c.Keysym = xproto.Keysym(keyName[0])
+		return c, nil
+	}
+	return Combo{}, fmt.Errorf("unsupported key: %s", keyName)
+}
+
+// KeysymForRune maps a character to its keysym: Latin-1 characters are
+// their own keysym, everything else uses the Unicode keysym range.
+func KeysymForRune(r rune) xproto.Keysym {
+	switch {
+	case r == '\n':
+		return namedKeysyms["enter"]
+	case r == '\t':
+		return namedKeysyms["tab"]
+	case r >= 0x20 && r <= 0x7e, r >= 0xa0 && r <= 0xff:
+		return xproto.Keysym(r)
+	default:
+		return xproto.Keysym(0x01000000 | r)
+	}
+}
@@ -0,0 +1,61 @@
+package keyboard
+
+import (
+	"testing"
+
+	"github.com/BurntSushi/xgb/xproto"
+)
+
+func TestParseCombo(t *testing.T) {
+	tests := []struct {
+		combo  string
+		mask   uint16
+		keysym xproto.Keysym
+	}{
+		{"a", 0, 'a'},
+		{"ctrl+s", xproto.ModMaskControl, 's'},
+		{"Ctrl+Shift+Alt+A", xproto.ModMaskControl | xproto.ModMaskShift | xproto.ModMask1, 'a'},
+		{"super+5", xproto.ModMask4, '5'},
+		{"alt+f4", xproto.ModMask1, 0xffc1},
+		{"enter", 0, 0xff0d},
+		{"shift+tab", xproto.ModMaskShift, 0xff09},
+	}
+	for _, tt := range tests {
+		c, err := ParseCombo(tt.combo)
+		if err != nil {
+			t.Errorf("ParseCombo(%q) returned %v", tt.combo, err)
+			continue
+		}
+		if c.Mask() != tt.mask || c.Keysym != tt.keysym {
+			t.Errorf("ParseCombo(%q) = mask %#x, keysym %#x, want %#x, %#x", tt.combo, c.Mask(), c.Keysym, tt.mask, tt.keysym)
+		}
+	}
+}
+
+func TestParseComboErrors(t *testing.T) {
+	for _, combo := range []string{"", "ctrl+", "hyper+a", "ctrl+ab", "ctrl+!"} {
+		if _, err := ParseCombo(combo); err == nil {
+			t.Errorf("ParseCombo(%q) accepted", combo)
+		}
+	}
+}
+
+func TestKeysymForRune(t *testing.T) {
+	tests := []struct {
+		r    rune
+		want xproto.Keysym
+	}{
+		{'a', 'a'},
+		{'Z', 'Z'},
+		{' ', 0x20},
+		{'\n', 0xff0d},
+		{'\t', 0xff09},
+		{'é', 0xe9},
+		{'€', 0x010020ac},
+	}
+	for _, tt := range tests {
+		if got := KeysymForRune(tt.r); got != tt.want {
+			t.Errorf("KeysymForRune(%q) = %#x, want %#x", tt.r, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,198 @@
+//go:build linux
+// +build linux
+
+package keyboard
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/BurntSushi/xgb"
+	"github.com/BurntSushi/xgb/xproto"
+	"github.com/BurntSushi/xgb/xtest"
+)
+
+// Keyboard resolves keysyms to keycodes and synthesizes key events.
+type Keyboard struct {
+	conn    *xgb.Conn
+	root    xproto.Window
+	window  xproto.Window
+	xtest   bool
+	delay   time.Duration
+	min     xproto.Keycode
+	per     int
+	keysyms []xproto.Keysym
+	scratch xproto.Keycode
+}
+
+// New reads the keyboard mapping. With useXTest, key events go to the
+// focused window through XTEST; otherwise they are sent to window.
+func New(conn *xgb.Conn, window xproto.Window, useXTest bool, delay time.Duration) (*Keyboard, error) {
+	setup := xproto.Setup(conn)
+	count := byte(setup.MaxKeycode - setup.MinKeycode + 1)
+	reply, err := xproto.GetKeyboardMapping(conn, setup.MinKeycode, count).Reply()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get keyboard mapping: %v", err)
+	}
+
+	if useXTest {
+		if err := xtest.Init(conn); err != nil {
+			return nil, fmt.Errorf("XTEST extension not available: %v", err)
+		}
+	}
+
+	return &Keyboard{
+		conn:    conn,
+		root:    setup.DefaultScreen(conn).Root,
+		window:  window,
+		xtest:   useXTest,
+		delay:   delay,
+		min:     setup.MinKeycode,
+		per:     int(reply.KeysymsPerKeycode),
+		keysyms: reply.Keysyms,
+	}, nil
+}
+
+// keycode finds the key producing the keysym, and whether shift must be
+// held for it. Keysyms missing from the keyboard are temporarily mapped to
+// an unused keycode, as long as the keyboard has one.
+func (kb *Keyboard) keycode(keysym xproto.Keysym) (xproto.Keycode, bool, error) {
+	if keycode, ok := kb.lookup(keysym); ok {
+		return keycode, kb.shifted(keycode, keysym), nil
+	}
+
+	if kb.scratch == 0 {
+		for i := len(kb.keysyms) - kb.per; i >= 0; i -= kb.per {
+			unused := true
+			for col := 0; col < kb.per; col++ {
+				unused = unused && kb.keysyms[i+col] == 0
+			}
+			if unused {
+				kb.scratch = kb.min + xproto.Keycode(i/kb.per)
+				break
+			}
+		}
+		if kb.scratch == 0 {
+			return 0, false, fmt.Errorf("no key for keysym 0x%x and no free keycode to map it to", keysym)
+		}
+	}
+
+	kb.remap(keysym)
+	return kb.scratch, false, nil
+}
+
+// lookup finds the key producing the keysym on its own or with shift.
+func (kb *Keyboard) lookup(keysym xproto.Keysym) (xproto.Keycode, bool) {
+	for i := 0; i+kb.per <= len(kb.keysyms); i += kb.per {
+		for col := 0; col < 2 && col < kb.per; col++ {
+			if kb.keysyms[i+col] == keysym {
+				return kb.min + xproto.Keycode(i/kb.per), true
+			}
+		}
+	}
+	return 0, false
+}
+
+// shifted reports whether the key produces the keysym only with shift.
+func (kb *Keyboard) shifted(keycode xproto.Keycode, keysym xproto.Keysym) bool {
+	i := int(keycode-kb.min) * kb.per
+	return kb.keysyms[i] != keysym
+}
+
+// remap points the scratch keycode at the keysym and waits for the server
+// to apply it, so the next key event already uses the new mapping.
+func (kb *Keyboard) remap(keysym xproto.Keysym) {
+	keysyms := make([]xproto.Keysym, kb.per)
+	for i := range keysyms {
+		keysyms[i] = keysym
+	}
+	xproto.ChangeKeyboardMapping(kb.conn, 1, kb.scratch, byte(kb.per), keysyms)
+	xproto.GetInputFocus(kb.conn).Reply()
+	time.Sleep(kb.delay)
+}
+
+// Close removes the temporary mapping of the scratch keycode.
+func (kb *Keyboard) Close() {
+	if kb.scratch != 0 {
+		kb.remap(0)
+	}
+}
+
+func (kb *Keyboard) send(keycode xproto.Keycode, press bool, state uint16) {
+	if kb.xtest {
+		eventType := byte(xproto.KeyRelease)
+		if press {
+			eventType = xproto.KeyPress
+		}
+		xtest.FakeInput(kb.conn, eventType, byte(keycode), 0, kb.root, 0, 0, 0)
+		return
+	}
+
+	// Synthetic events carry the modifier state themselves. Applications
+	// may ignore them, which is why XTEST is the default.
+	event := xproto.KeyPressEvent{
+		Detail:     keycode,
+		Time:       xproto.TimeCurrentTime,
+		Root:       kb.root,
+		Event:      kb.window,
+		State:      state,
+		SameScreen: true,
+	}
+	if press {
+		xproto.SendEvent(kb.conn, true, kb.window, xproto.EventMaskKeyPress, string(event.Bytes()))
+	} else {
+		release := xproto.KeyReleaseEvent(event)
+		xproto.SendEvent(kb.conn, true, kb.window, xproto.EventMaskKeyRelease, string(release.Bytes()))
+	}
+}
+
+// Tap presses and releases the key of the combination with its modifiers
+// held.
+func (kb *Keyboard) Tap(c Combo) error {
+	keycode, shift, err := kb.keycode(c.Keysym)
+	if err != nil {
+		return err
+	}
+	mods := c.Mods
+	if shift {
+		mods = append(mods[:len(mods):len(mods)], modifiers["shift"])
+	}
+
+	var state uint16
+	var held []xproto.Keycode
+	for _, mod := range mods {
+		state |= mod.Mask
+		if kb.xtest {
+			modKeycode, _, err := kb.keycode(mod.Keysym)
+			if err != nil {
+				return err
+			}
+			kb.send(modKeycode, true, 0)
+			held = append(held, modKeycode)
+		}
+	}
+
+	kb.send(keycode, true, state)
+	kb.send(keycode, false, state)
+
+	for i := len(held) - 1; i >= 0; i-- {
+		kb.send(held[i], false, 0)
+	}
+
+	xproto.GetInputFocus(kb.conn).Reply()
+	time.Sleep(kb.delay)
+	return nil
+}
+
+// Keycode returns the key producing the keysym without shift or with it,
+// for grabbing it as a hotkey.
+func Keycode(conn *xgb.Conn, keysym xproto.Keysym) (xproto.Keycode, error) {
+	kb, err := New(conn, 0, false, 0)
+	if err != nil {
+		return 0, err
+	}
+	if keycode, ok := kb.lookup(keysym); ok {
+		return keycode, nil
+	}
+	return 0, fmt.Errorf("no key for keysym 0x%x", keysym)
+}
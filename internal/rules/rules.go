@@ -0,0 +1,205 @@
+// Package rules reads the rules file of gwc-rules and works out which
+// rules apply to a window and where they put it.
+package rules
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Match selects windows. Every field that is set must match.
+type Match struct {
+	Class   string `json:"class,omitempty"`   // window class, or on X11 the WM_CLASS instance or class name
+	Title   string `json:"title,omitempty"`   // part of the title
+	Process string `json:"process,omitempty"` // executable name, without .exe on Windows
+}
+
+// Rule is one entry of the rules file. Positions and sizes are pixels, or
+// a percentage of the monitor's work area with a % suffix; x and y are
+// relative to the work area and default to centering the window on it.
+type Rule struct {
+	Name    string `json:"name,omitempty"`
+	Match   Match  `json:"match"`
+	Monitor int    `json:"monitor,omitempty"` // 1-based, 0 for the monitor the window is on
+	X       string `json:"x,omitempty"`
+	Y       string `json:"y,omitempty"`
+	Width   string `json:"width,omitempty"`
+	Height  string `json:"height,omitempty"`
+	Desktop *int   `json:"desktop,omitempty"` // 0-based, -1 for all desktops
+	Above   bool   `json:"above,omitempty"`
+}
+
+// Window is what rules are matched against.
+type Window struct {
+	Title   string
+	Class   []string
+	Process string
+}
+
+// Rect is a window's or a work area's position and size in pixels.
+type Rect struct {
+	X, Y, Width, Height int
+}
+
+// Intersect returns the part of r inside o. ok is false if they do not
+// overlap.
+func (r Rect) Intersect(o Rect) (Rect, bool) {
+	x, y := max(r.X, o.X), max(r.Y, o.Y)
+	right, bottom := min(r.X+r.Width, o.X+o.Width), min(r.Y+r.Height, o.Y+o.Height)
+	if right <= x || bottom <= y {
+		return Rect{}, false
+	}
+	return Rect{x, y, right - x, bottom - y}, true
+}
+
+// parseLength parses a rule position or size: a number of pixels, or a
+// percentage with a % suffix.
+func parseLength(value string) (float64, bool, error) {
+	if strings.HasSuffix(value, "%") {
+		pct, err := strconv.ParseFloat(strings.TrimSuffix(value, "%"), 64)
+		if err != nil {
+			return 0, false, fmt.Errorf("invalid percentage '%s'", value)
+		}
+		return pct, true, nil
+	}
+	px, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, false, fmt.Errorf("invalid length '%s'", value)
+	}
+	return float64(px), false, nil
+}
+
+// ResolveLength turns a rule position or size into pixels; percentages are
+// of total.
+func ResolveLength(value string, total int) (int, error) {
+	n, pct, err := parseLength(value)
+	if err != nil {
+		return 0, err
+	}
+	if pct {
+		return int(float64(total) * n / 100), nil
+	}
+	return int(n), nil
+}
+
+func (r Rule) Label() string {
+	if r.Name != "" {
+		return r.Name
+	}
+	return fmt.Sprintf("%+v", r.Match)
+}
+
+func (r Rule) HasGeometry() bool {
+	return r.Monitor != 0 || r.X != "" || r.Y != "" || r.Width != "" || r.Height != ""
+}
+
+func (r Rule) Validate() error {
+	if r.Match == (Match{}) {
+		return fmt.Errorf("rule %s: match needs a class, title or process", r.Label())
+	}
+	if r.Monitor < 0 {
+		return fmt.Errorf("rule %s: monitor must be 1 or more", r.Label())
+	}
+	for _, value := range []string{r.X, r.Y} {
+		if value == "" {
+			continue
+		}
+		if _, _, err := parseLength(value); err != nil {
+			return fmt.Errorf("rule %s: %v", r.Label(), err)
+		}
+	}
+	for _, size := range []struct{ name, value string }{{"width", r.Width}, {"height", r.Height}} {
+		if size.value == "" {
+			continue
+		}
+		n, _, err := parseLength(size.value)
+		if err != nil {
+			return fmt.Errorf("rule %s: %v", r.Label(), err)
+		}
+		if n <= 0 {
+			return fmt.Errorf("rule %s: %s must be more than 0", r.Label(), size.name)
+		}
+	}
+	if r.Desktop != nil && *r.Desktop < -1 {
+		return fmt.Errorf("rule %s: desktop must be 0 or more, or -1 for all desktops", r.Label())
+	}
+	return nil
+}
+
+func (r Rule) Matches(w Window) bool {
+	if r.Match.Class != "" {
+		found := false
+		for _, class := range w.Class {
+			if strings.EqualFold(class, r.Match.Class) {
+				found = true
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if r.Match.Title != "" && !strings.Contains(strings.ToLower(w.Title), strings.ToLower(r.Match.Title)) {
+		return false
+	}
+	if r.Match.Process != "" && !strings.EqualFold(w.Process, r.Match.Process) {
+		return false
+	}
+	return true
+}
+
+// Geometry works out where the rule puts a window of the current size on
+// the work area. The rule must have been validated.
+func (r Rule) Geometry(current, work Rect) Rect {
+	target := Rect{Width: current.Width, Height: current.Height}
+	if r.Width != "" {
+		target.Width, _ = ResolveLength(r.Width, work.Width)
+	}
+	if r.Height != "" {
+		target.Height, _ = ResolveLength(r.Height, work.Height)
+	}
+	// A small percentage of a small work area can round down to nothing.
+	target.Width, target.Height = max(target.Width, 1), max(target.Height, 1)
+
+	target.X = work.X + (work.Width-target.Width)/2
+	if r.X != "" {
+		x, _ := ResolveLength(r.X, work.Width)
+		target.X = work.X + x
+	}
+	target.Y = work.Y + (work.Height-target.Height)/2
+	if r.Y != "" {
+		y, _ := ResolveLength(r.Y, work.Height)
+		target.Y = work.Y + y
+	}
+	return target
+}
+
+// Load reads and validates a rules file.
+func Load(path string) ([]Rule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var rules []Rule
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("invalid rules file %s: %v", path, err)
+	}
+	for _, r := range rules {
+		if err := r.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	return rules, nil
+}
+
+// DefaultPath returns rules.json in the gwctl configuration directory.
+func DefaultPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "rules.json"
+	}
+	return filepath.Join(dir, "gwctl", "rules.json")
+}
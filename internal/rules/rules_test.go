@@ -0,0 +1,153 @@
+package rules
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestResolveLength(t *testing.T) {
+	tests := []struct {
+		value   string
+		total   int
+		want    int
+		wantErr bool
+	}{
+		{"100", 1920, 100, false},
+		{"-20", 1920, -20, false},
+		{"50%", 1920, 960, false},
+		{"33.3%", 1000, 333, false},
+		{"0%", 1920, 0, false},
+		{"abc", 1920, 0, true},
+		{"10px", 1920, 0, true},
+		{"%", 1920, 0, true},
+	}
+	for _, tt := range tests {
+		got, err := ResolveLength(tt.value, tt.total)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ResolveLength(%q, %d) = %d, %v, want %d, error %t", tt.value, tt.total, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	match := Match{Class: "slack"}
+	desktop := func(n int) *int { return &n }
+	tests := []struct {
+		name    string
+		rule    Rule
+		wantErr string
+	}{
+		{"minimal", Rule{Match: match}, ""},
+		{"full", Rule{Match: match, Monitor: 2, X: "10", Y: "-10", Width: "40%", Height: "100%", Desktop: desktop(-1), Above: true}, ""},
+		{"no match", Rule{Width: "100"}, "match needs"},
+		{"negative monitor", Rule{Match: match, Monitor: -1}, "monitor"},
+		{"bad x", Rule{Match: match, X: "left"}, "invalid length"},
+		{"bad width", Rule{Match: match, Width: "wide%"}, "invalid percentage"},
+		{"zero width", Rule{Match: match, Width: "0"}, "width must be more than 0"},
+		{"zero percent height", Rule{Match: match, Height: "0%"}, "height must be more than 0"},
+		{"negative height", Rule{Match: match, Height: "-100"}, "height must be more than 0"},
+		{"bad desktop", Rule{Match: match, Desktop: desktop(-2)}, "desktop"},
+	}
+	for _, tt := range tests {
+		err := tt.rule.Validate()
+		switch {
+		case tt.wantErr == "" && err != nil:
+			t.Errorf("%s: Validate returned %v", tt.name, err)
+		case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+			t.Errorf("%s: Validate returned %v, want an error containing %q", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestMatches(t *testing.T) {
+	window := Window{Title: "General - Slack", Class: []string{"slack", "Slack"}, Process: "slack"}
+	tests := []struct {
+		match Match
+		want  bool
+	}{
+		{Match{Class: "SLACK"}, true},
+		{Match{Title: "general"}, true},
+		{Match{Process: "Slack"}, true},
+		{Match{Class: "slack", Title: "general", Process: "slack"}, true},
+		{Match{Class: "firefox"}, false},
+		{Match{Class: "slack", Title: "random"}, false},
+		{Match{Process: "slac"}, false},
+	}
+	for _, tt := range tests {
+		if got := (Rule{Match: tt.match}).Matches(window); got != tt.want {
+			t.Errorf("Matches(%+v) = %t, want %t", tt.match, got, tt.want)
+		}
+	}
+}
+
+func TestGeometry(t *testing.T) {
+	current := Rect{X: 5, Y: 5, Width: 800, Height: 600}
+	work := Rect{X: 1920, Y: 30, Width: 1920, Height: 1050}
+	tests := []struct {
+		name string
+		rule Rule
+		want Rect
+	}{
+		{"centered", Rule{}, Rect{2480, 255, 800, 600}},
+		{"percent size", Rule{Width: "40%", Height: "100%"}, Rect{2496, 30, 768, 1050}},
+		{"pixels", Rule{X: "0", Y: "10", Width: "1000", Height: "500"}, Rect{1920, 40, 1000, 500}},
+		{"percent position", Rule{X: "50%", Y: "0%"}, Rect{2880, 30, 800, 600}},
+		{"rounds to nothing", Rule{Width: "0.01%", Height: "0.01%"}, Rect{2879, 554, 1, 1}},
+	}
+	for _, tt := range tests {
+		if got := tt.rule.Geometry(current, work); got != tt.want {
+			t.Errorf("%s: Geometry = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestIntersect(t *testing.T) {
+	monitor := Rect{X: 1920, Y: 0, Width: 1920, Height: 1080}
+	tests := []struct {
+		other  Rect
+		want   Rect
+		wantOK bool
+	}{
+		// A _NET_WORKAREA spanning both monitors, minus a top panel.
+		{Rect{0, 30, 3840, 1050}, Rect{1920, 30, 1920, 1050}, true},
+		{Rect{0, 0, 1920, 1080}, Rect{}, false},
+		{monitor, monitor, true},
+	}
+	for _, tt := range tests {
+		got, ok := monitor.Intersect(tt.other)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("Intersect(%+v) = %+v, %t, want %+v, %t", tt.other, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, data string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(data), 0600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	rules, err := Load(write("ok.json", `[{"name": "chat", "match": {"class": "slack"}, "monitor": 2, "width": "40%", "desktop": 3, "above": true}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rules) != 1 || rules[0].Label() != "chat" || rules[0].Monitor != 2 || *rules[0].Desktop != 3 || !rules[0].Above {
+		t.Errorf("Load = %+v", rules)
+	}
+
+	if _, err := Load(write("syntax.json", `[{"match": `)); err == nil {
+		t.Error("Load accepted invalid JSON")
+	}
+	if _, err := Load(write("invalid.json", `[{"match": {"class": "slack"}, "width": "0"}]`)); err == nil {
+		t.Error("Load accepted an invalid rule")
+	}
+	if _, err := Load(filepath.Join(dir, "missing.json")); err == nil {
+		t.Error("Load accepted a missing file")
+	}
+}
@@ -0,0 +1,78 @@
+// Package target chooses the window a gwctl command acts on: by title, or
+// with -pick, -active or -under-cursor.
+package target
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// Flags are the ways of choosing the window other than by title.
+type Flags struct {
+	Pick, Active, UnderCursor bool
+}
+
+// Register adds -pick, -active and -under-cursor to the command line.
+func (f *Flags) Register() {
+	flag.BoolVar(&f.Pick, "pick", false, "Click the window to act on instead of giving its title")
+	flag.BoolVar(&f.Active, "active", false, "Act on the active window instead of giving its title")
+	flag.BoolVar(&f.UnderCursor, "under-cursor", false, "Act on the window under the mouse pointer instead of giving its title")
+}
+
+// check makes sure the window is chosen in exactly one way. An empty title
+// must never reach a title search, where it would match any window.
+func (f Flags) check(title string) error {
+	given := 0
+	for _, set := range []bool{title != "", f.Pick, f.Active, f.UnderCursor} {
+		if set {
+			given++
+		}
+	}
+	if given == 0 {
+		return errors.New("no window given, use -title, -pick, -active or -under-cursor")
+	}
+	if given > 1 {
+		return errors.New("use only one of -title, -pick, -active and -under-cursor")
+	}
+	return nil
+}
+
+// pickerPath returns gwc-pick from the same directory as this program, as
+// built by go build or by the Makefile (which names builds after their
+// source files), or else from the PATH.
+func pickerPath() string {
+	exe, err := os.Executable()
+	if err != nil {
+		return "gwc-pick"
+	}
+	for _, name := range []string{"gwc-pick", "gwc-pick_" + runtime.GOOS} {
+		sibling := filepath.Join(filepath.Dir(exe), name+filepath.Ext(exe))
+		if _, err := os.Stat(sibling); err == nil {
+			return sibling
+		}
+	}
+	return "gwc-pick"
+}
+
+// pick lets the user click the window, using gwc-pick. Its prompt and the
+// details of the picked window go to stderr.
+func pick() (uint64, error) {
+	cmd := exec.Command(pickerPath(), "-id")
+	cmd.Stderr = os.Stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return 0, fmt.Errorf("gwc-pick failed: %v", err)
+	}
+	id, err := strconv.ParseUint(strings.TrimSpace(string(out)), 0, 64)
+	if err != nil {
+		return 0, fmt.Errorf("unexpected gwc-pick output: %q", out)
+	}
+	return id, nil
+}
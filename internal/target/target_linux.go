@@ -0,0 +1,87 @@
+//go:build linux
+// +build linux
+
+package target
+
+import (
+	"fmt"
+
+	"github.com/BurntSushi/xgb"
+	"github.com/BurntSushi/xgb/xproto"
+	"gwctl/internal/x11"
+)
+
+// Window returns the window chosen by the flags, or else the one find
+// returns for the title.
+func Window(conn *xgb.Conn, title string, flags Flags,
+	find func(*xgb.Conn, string) (xproto.Window, error)) (xproto.Window, error) {
+	if err := flags.check(title); err != nil {
+		return 0, err
+	}
+	switch {
+	case flags.Pick:
+		id, err := pick()
+		return xproto.Window(id), err
+	case flags.Active:
+		return Active(conn)
+	case flags.UnderCursor:
+		return UnderCursor(conn)
+	}
+	return find(conn, title)
+}
+
+// Active returns the window the window manager reports as active.
+func Active(conn *xgb.Conn) (xproto.Window, error) {
+	activeAtom, err := x11.InternAtom(conn, "_NET_ACTIVE_WINDOW")
+	if err != nil {
+		return 0, err
+	}
+	root := xproto.Setup(conn).DefaultScreen(conn).Root
+	reply, err := xproto.GetProperty(conn, false, root,
+		activeAtom, xproto.AtomWindow, 0, 1).Reply()
+	if err != nil {
+		return 0, err
+	}
+	if len(reply.Value) < 4 {
+		return 0, fmt.Errorf("the window manager does not report the active window")
+	}
+	window := xproto.Window(xgb.Get32(reply.Value))
+	if window == 0 {
+		return 0, fmt.Errorf("no window is active")
+	}
+	return window, nil
+}
+
+// UnderCursor returns the client window under the mouse pointer.
+func UnderCursor(conn *xgb.Conn) (xproto.Window, error) {
+	root := xproto.Setup(conn).DefaultScreen(conn).Root
+	pointer, err := xproto.QueryPointer(conn, root).Reply()
+	if err != nil {
+		return 0, err
+	}
+	if pointer.Child == 0 {
+		return 0, fmt.Errorf("no window under the pointer")
+	}
+	if client := ClientWindow(conn, pointer.Child); client != 0 {
+		return client, nil
+	}
+	return pointer.Child, nil
+}
+
+// ClientWindow finds the client window inside a top-level window, which
+// is usually a window manager frame. It returns 0 if there is none.
+func ClientWindow(conn *xgb.Conn, window xproto.Window) xproto.Window {
+	if x11.IsClientWindow(conn, window) {
+		return window
+	}
+	tree, err := xproto.QueryTree(conn, window).Reply()
+	if err != nil {
+		return 0
+	}
+	for _, child := range tree.Children {
+		if client := ClientWindow(conn, child); client != 0 {
+			return client
+		}
+	}
+	return 0
+}
@@ -0,0 +1,67 @@
+package target
+
+import (
+	"fmt"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	modUser32               = syscall.NewLazyDLL("user32.dll")
+	procGetForegroundWindow = modUser32.NewProc("GetForegroundWindow")
+	procGetCursorPos        = modUser32.NewProc("GetCursorPos")
+	procWindowFromPoint     = modUser32.NewProc("WindowFromPoint")
+	procGetAncestor         = modUser32.NewProc("GetAncestor")
+	GA_ROOT                 = 2
+)
+
+// Window returns the window chosen by the flags, or else the one find
+// returns for the title.
+func Window(title string, flags Flags, find func(*uint16) (syscall.Handle, error)) (syscall.Handle, error) {
+	if err := flags.check(title); err != nil {
+		return 0, err
+	}
+	switch {
+	case flags.Pick:
+		id, err := pick()
+		return syscall.Handle(id), err
+	case flags.Active:
+		return Active()
+	case flags.UnderCursor:
+		return UnderCursor()
+	}
+	return find(syscall.StringToUTF16Ptr(title))
+}
+
+// Active returns the foreground window.
+func Active() (syscall.Handle, error) {
+	hwnd, _, _ := procGetForegroundWindow.Call()
+	if hwnd == 0 {
+		return 0, fmt.Errorf("no window is active")
+	}
+	return syscall.Handle(hwnd), nil
+}
+
+// UnderCursor returns the top-level window under the mouse pointer.
+func UnderCursor() (syscall.Handle, error) {
+	var pt struct {
+		x, y int32
+	}
+	ret, _, err := procGetCursorPos.Call(uintptr(unsafe.Pointer(&pt)))
+	if ret == 0 {
+		return 0, fmt.Errorf("failed to get pointer position: %v", err)
+	}
+
+	// POINT is passed by value, packed into a single argument on 64-bit.
+	var child uintptr
+	if unsafe.Sizeof(uintptr(0)) == 8 {
+		child, _, _ = procWindowFromPoint.Call(uintptr(uint32(pt.x)) | uintptr(uint32(pt.y))<<32)
+	} else {
+		child, _, _ = procWindowFromPoint.Call(uintptr(pt.x), uintptr(pt.y))
+	}
+	if child == 0 {
+		return 0, fmt.Errorf("no window under the pointer")
+	}
+	hwnd, _, _ := procGetAncestor.Call(child, uintptr(GA_ROOT))
+	return syscall.Handle(hwnd), nil
+}
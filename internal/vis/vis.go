@@ -0,0 +1,12 @@
+// Package vis hides and shows windows in one of several ways, for
+// gwc-hide-vis and gwc-show-vis.
+package vis
+
+// Modes, named as for gwc-tray's -hide-mode.
+const (
+	modeUnmap     = "unmap"     // unmap the window (default)
+	modeIconify   = "iconify"   // minimize it
+	modeOffscreen = "offscreen" // move it out of the visible screen area
+	modeHidden    = "hidden"    // set _NET_WM_STATE_HIDDEN
+	modeOpacity   = "opacity"   // make it fully transparent and lower it
+)
@@ -0,0 +1,299 @@
+//go:build linux
+// +build linux
+
+package vis
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/xgb"
+	"github.com/BurntSushi/xgb/xproto"
+	"gwctl/internal/target"
+	"gwctl/internal/x11"
+)
+
+// findWithdrawnWindow looks for an unmapped top-level window. Window managers
+// may drop WM_STATE from windows that were unmapped by gwc-hide-vis.
+func findWithdrawnWindow(conn *xgb.Conn, root xproto.Window, title string) xproto.Window {
+	treeReply, err := xproto.QueryTree(conn, root).Reply()
+	if err != nil {
+		return 0
+	}
+	for _, child := range treeReply.Children {
+		attrs, err := xproto.GetWindowAttributes(conn, child).Reply()
+		if err != nil || attrs.MapState != xproto.MapStateUnmapped || attrs.OverrideRedirect {
+			continue
+		}
+		windowName := x11.WindowName(conn, child)
+		if windowName != "" && strings.Contains(strings.ToLower(windowName), strings.ToLower(title)) {
+			return child
+		}
+	}
+	return 0
+}
+
+func findWindow(conn *xgb.Conn, title string) (xproto.Window, error) {
+	window, err := x11.FindWindow(conn, title)
+	if err == nil {
+		return window, nil
+	}
+	root := xproto.Setup(conn).DefaultScreen(conn).Root
+	if window := findWithdrawnWindow(conn, root, title); window != 0 {
+		return window, nil
+	}
+	return 0, err
+}
+
+const (
+	iconicState = 3
+
+	// Far enough to be off every monitor, close enough to fit in an int16.
+	offscreenPosition = -30000
+)
+
+// Window properties used to hand the original state over between
+// gwc-hide-vis and gwc-show-vis.
+const (
+	propOffscreen = "_GWCTL_OFFSCREEN"
+	propOpacity   = "_GWCTL_OPACITY"
+)
+
+func getCardinals(conn *xgb.Conn, window xproto.Window, name string, typ xproto.Atom, n uint32) ([]uint32, bool) {
+	atom, err := x11.InternAtom(conn, name)
+	if err != nil {
+		return nil, false
+	}
+	reply, err := xproto.GetProperty(conn, false, window, atom, typ, 0, n).Reply()
+	if err != nil || reply == nil || len(reply.Value) < int(n)*4 {
+		return nil, false
+	}
+	values := make([]uint32, n)
+	for i := range values {
+		values[i] = xgb.Get32(reply.Value[i*4:])
+	}
+	return values, true
+}
+
+func setCardinals(conn *xgb.Conn, window xproto.Window, name string, values ...uint32) error {
+	atom, err := x11.InternAtom(conn, name)
+	if err != nil {
+		return err
+	}
+	buf := make([]byte, 4*len(values))
+	for i, value := range values {
+		xgb.Put32(buf[i*4:], value)
+	}
+	return xproto.ChangePropertyChecked(conn, xproto.PropModeReplace, window,
+		atom, xproto.AtomCardinal, 32, uint32(len(values)), buf).Check()
+}
+
+func deleteProperty(conn *xgb.Conn, window xproto.Window, name string) {
+	if atom, err := x11.InternAtom(conn, name); err == nil {
+		xproto.DeleteProperty(conn, window, atom)
+	}
+}
+
+func isValidMode(mode string) bool {
+	switch mode {
+	case modeUnmap, modeIconify, modeOffscreen, modeHidden, modeOpacity:
+		return true
+	}
+	return false
+}
+
+// isHiddenWithMode reports whether the window is currently hidden the way
+// the mode hides it.
+func isHiddenWithMode(conn *xgb.Conn, window xproto.Window, mode string) bool {
+	switch mode {
+	case modeIconify:
+		wmState, err := x11.InternAtom(conn, "WM_STATE")
+		if err != nil {
+			return false
+		}
+		state, ok := getCardinals(conn, window, "WM_STATE", wmState, 1)
+		return ok && state[0] == iconicState || x11.HasWindowState(conn, window, "_NET_WM_STATE_HIDDEN")
+	case modeHidden:
+		return x11.HasWindowState(conn, window, "_NET_WM_STATE_HIDDEN")
+	case modeOffscreen:
+		root := xproto.Setup(conn).DefaultScreen(conn).Root
+		pos, err := xproto.TranslateCoordinates(conn, window, root, 0, 0).Reply()
+		return err == nil && int(pos.DstX) <= offscreenPosition/2 && int(pos.DstY) <= offscreenPosition/2
+	case modeOpacity:
+		opacity, ok := getCardinals(conn, window, "_NET_WM_WINDOW_OPACITY", xproto.AtomCardinal, 1)
+		return ok && opacity[0] == 0
+	default:
+		attrs, err := xproto.GetWindowAttributes(conn, window).Reply()
+		return err == nil && attrs.MapState == xproto.MapStateUnmapped
+	}
+}
+
+// frameExtents returns the left and top decoration sizes, since configure
+// requests position the frame, not the client inside it.
+func frameExtents(conn *xgb.Conn, window xproto.Window) (int32, int32) {
+	extents, ok := getCardinals(conn, window, "_NET_FRAME_EXTENTS", xproto.AtomCardinal, 4)
+	if !ok {
+		return 0, 0
+	}
+	return int32(extents[0]), int32(extents[2])
+}
+
+func moveWindow(conn *xgb.Conn, window xproto.Window, x, y int32) {
+	xproto.ConfigureWindow(conn, window, xproto.ConfigWindowX|xproto.ConfigWindowY,
+		[]uint32{uint32(x), uint32(y)})
+}
+
+func hideWithMode(conn *xgb.Conn, window xproto.Window, mode string) error {
+	switch mode {
+	case modeIconify:
+		return x11.SendClientMessage(conn, window, "WM_CHANGE_STATE", iconicState)
+	case modeHidden:
+		return x11.SetWindowStates(conn, window, x11.StateAdd, "_NET_WM_STATE_HIDDEN")
+	case modeOffscreen:
+		root := xproto.Setup(conn).DefaultScreen(conn).Root
+		pos, err := xproto.TranslateCoordinates(conn, window, root, 0, 0).Reply()
+		if err != nil {
+			return fmt.Errorf("failed to get window position: %v", err)
+		}
+		// An already hidden window keeps the position it was hidden from.
+		if int(pos.DstX) > offscreenPosition/2 || int(pos.DstY) > offscreenPosition/2 {
+			left, top := frameExtents(conn, window)
+			x, y := int32(pos.DstX)-left, int32(pos.DstY)-top
+			if err := setCardinals(conn, window, propOffscreen, uint32(x), uint32(y)); err != nil {
+				return err
+			}
+		}
+		moveWindow(conn, window, offscreenPosition, offscreenPosition)
+	case modeOpacity:
+		if opacity, ok := getCardinals(conn, window, "_NET_WM_WINDOW_OPACITY", xproto.AtomCardinal, 1); ok && opacity[0] != 0 {
+			setCardinals(conn, window, propOpacity, opacity[0])
+		}
+		if err := setCardinals(conn, window, "_NET_WM_WINDOW_OPACITY", 0); err != nil {
+			return err
+		}
+		// A transparent window still takes clicks, so keep it out of the way.
+		xproto.ConfigureWindow(conn, window, xproto.ConfigWindowStackMode, []uint32{xproto.StackModeBelow})
+	default:
+		return xproto.UnmapWindowChecked(conn, window).Check()
+	}
+	return nil
+}
+
+func showWithMode(conn *xgb.Conn, window xproto.Window, mode string) error {
+	switch mode {
+	case modeIconify:
+		return x11.SendClientMessage(conn, window, "_NET_ACTIVE_WINDOW", x11.SourcePager, uint32(xproto.TimeCurrentTime))
+	case modeHidden:
+		return x11.SetWindowStates(conn, window, x11.StateRemove, "_NET_WM_STATE_HIDDEN")
+	case modeOffscreen:
+		pos, ok := getCardinals(conn, window, propOffscreen, xproto.AtomCardinal, 2)
+		if !ok {
+			return fmt.Errorf("window was not hidden with -mode offscreen")
+		}
+		deleteProperty(conn, window, propOffscreen)
+		moveWindow(conn, window, int32(pos[0]), int32(pos[1]))
+	case modeOpacity:
+		if opacity, ok := getCardinals(conn, window, propOpacity, xproto.AtomCardinal, 1); ok {
+			deleteProperty(conn, window, propOpacity)
+			setCardinals(conn, window, "_NET_WM_WINDOW_OPACITY", opacity[0])
+		} else {
+			deleteProperty(conn, window, "_NET_WM_WINDOW_OPACITY")
+		}
+		xproto.ConfigureWindow(conn, window, xproto.ConfigWindowStackMode, []uint32{xproto.StackModeAbove})
+	default:
+		return xproto.MapWindowChecked(conn, window).Check()
+	}
+	return nil
+}
+
+// waitForHidden waits until the window is hidden or shown as wanted, or
+// until timeout, and returns the state it ended up in.
+func waitForHidden(conn *xgb.Conn, window xproto.Window, mode string, want bool, timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for {
+		hidden := isHiddenWithMode(conn, window, mode)
+		if hidden == want || !time.Now().Before(deadline) {
+			return hidden
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+// Run is the whole of gwc-hide-vis (hide true) and gwc-show-vis (hide
+// false); the two differ only in which way they act without toggle.
+func Run(hide bool) {
+	action, modeHelp := "hide", "How to hide the window"
+	if !hide {
+		action, modeHelp = "show", "How the window was hidden"
+	}
+
+	var windowTitle, mode string
+	var targetFlags target.Flags
+	var timeout time.Duration
+	flag.StringVar(&windowTitle, "title", "", "Window title to find and "+action)
+	targetFlags.Register()
+	flag.StringVar(&mode, "mode", modeUnmap, modeHelp+": unmap, iconify, offscreen, hidden or opacity")
+	flag.DurationVar(&timeout, "timeout", time.Second, "How long to wait for the window manager with toggle")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "Usage: gwc-"+action+"-vis -title TITLE [-mode MODE] [toggle]")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	toggle := false
+	switch {
+	case flag.NArg() == 0:
+	case flag.NArg() == 1 && flag.Arg(0) == "toggle":
+		toggle = true
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if !isValidMode(mode) {
+		fmt.Println("Unknown mode:", mode)
+		os.Exit(2)
+	}
+
+	conn, err := xgb.NewConn()
+	if err != nil {
+		fmt.Println("Error opening display:", err)
+		os.Exit(1)
+	}
+	defer conn.Close()
+
+	window, err := target.Window(conn, windowTitle, targetFlags, findWindow)
+	if err != nil {
+		fmt.Println("Error finding window:", err)
+		os.Exit(1)
+	}
+
+	if toggle {
+		hide = !isHiddenWithMode(conn, window, mode)
+	}
+
+	if hide {
+		err = hideWithMode(conn, window, mode)
+	} else {
+		err = showWithMode(conn, window, mode)
+	}
+	if err != nil {
+		if hide {
+			fmt.Println("Error hiding window:", err)
+		} else {
+			fmt.Println("Error showing window:", err)
+		}
+		os.Exit(1)
+	}
+
+	if toggle {
+		if waitForHidden(conn, window, mode, hide, timeout) {
+			fmt.Println("Window is hidden.")
+		} else {
+			fmt.Println("Window is shown.")
+		}
+	}
+}
@@ -0,0 +1,313 @@
+package vis
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"syscall"
+	"unsafe"
+
+	"gwctl/internal/target"
+)
+
+var (
+	modUser32                      = syscall.NewLazyDLL("user32.dll")
+	procFindWindow                 = modUser32.NewProc("FindWindowW")
+	procShowWindow                 = modUser32.NewProc("ShowWindow")
+	procIsWindowVisible            = modUser32.NewProc("IsWindowVisible")
+	procIsIconic                   = modUser32.NewProc("IsIconic")
+	procGetWindowRect              = modUser32.NewProc("GetWindowRect")
+	procSetWindowPos               = modUser32.NewProc("SetWindowPos")
+	procGetWindowLong              = modUser32.NewProc("GetWindowLongW")
+	procSetWindowLong              = modUser32.NewProc("SetWindowLongW")
+	procGetLayeredWindowAttributes = modUser32.NewProc("GetLayeredWindowAttributes")
+	procSetLayeredWindowAttributes = modUser32.NewProc("SetLayeredWindowAttributes")
+	procSetProp                    = modUser32.NewProc("SetPropW")
+	procGetProp                    = modUser32.NewProc("GetPropW")
+	procRemoveProp                 = modUser32.NewProc("RemovePropW")
+	procGetSystemMetrics           = modUser32.NewProc("GetSystemMetrics")
+	SW_HIDE                        = 0
+	SW_SHOW                        = 5
+	SW_SHOWMINNOACTIVE             = 7
+	SW_RESTORE                     = 9
+	SWP_NOSIZE                     = 0x0001
+	SWP_NOZORDER                   = 0x0004
+	SWP_NOACTIVATE                 = 0x0010
+	GWL_EXSTYLE                    = -20
+	WS_EX_LAYERED                  = 0x00080000
+	LWA_ALPHA                      = 0x00000002
+	SM_XVIRTUALSCREEN              = 76
+	SM_YVIRTUALSCREEN              = 77
+	SM_CXVIRTUALSCREEN             = 78
+	SM_CYVIRTUALSCREEN             = 79
+)
+
+// Window properties used to hand the original state over between
+// gwc-hide-vis and gwc-show-vis.
+const (
+	propOffscreen  = "gwctl.offscreen"
+	propOffscreenX = "gwctl.offscreen.x"
+	propOffscreenY = "gwctl.offscreen.y"
+	propLayered    = "gwctl.layered"
+)
+
+func findWindow(windowName *uint16) (syscall.Handle, error) {
+	ret, _, err := procFindWindow.Call(uintptr(unsafe.Pointer(nil)), uintptr(unsafe.Pointer(windowName)))
+	if ret == 0 {
+		return 0, err
+	}
+	return syscall.Handle(ret), nil
+}
+
+func setProp(hwnd syscall.Handle, name string, value uintptr) {
+	procSetProp.Call(uintptr(hwnd), uintptr(unsafe.Pointer(syscall.StringToUTF16Ptr(name))), value)
+}
+
+func hasProp(hwnd syscall.Handle, name string) bool {
+	value, _, _ := procGetProp.Call(uintptr(hwnd), uintptr(unsafe.Pointer(syscall.StringToUTF16Ptr(name))))
+	return value != 0
+}
+
+// takeProp reads and removes a window property. ok is false if it was not set.
+func takeProp(hwnd syscall.Handle, name string) (uintptr, bool) {
+	namePtr := uintptr(unsafe.Pointer(syscall.StringToUTF16Ptr(name)))
+	value, _, _ := procGetProp.Call(uintptr(hwnd), namePtr)
+	removed, _, _ := procRemoveProp.Call(uintptr(hwnd), namePtr)
+	return value, removed != 0
+}
+
+// isValidMode accepts the modes of gwc-tray's -hide-mode, apart from
+// hidden, which is an EWMH window state with no Windows counterpart.
+func isValidMode(mode string) bool {
+	switch mode {
+	case modeUnmap, modeIconify, modeOffscreen, modeOpacity:
+		return true
+	}
+	return false
+}
+
+// isHiddenWithMode reports whether the window is currently hidden the way
+// the mode hides it.
+func isHiddenWithMode(hwnd syscall.Handle, mode string) bool {
+	switch mode {
+	case modeIconify:
+		ret, _, _ := procIsIconic.Call(uintptr(hwnd))
+		return ret != 0
+	case modeOffscreen:
+		// Minimized windows sit at -32000 too, so the position proves nothing.
+		return hasProp(hwnd, propOffscreen)
+	case modeOpacity:
+		style, _, _ := procGetWindowLong.Call(uintptr(hwnd), uintptr(GWL_EXSTYLE))
+		if style&uintptr(WS_EX_LAYERED) == 0 {
+			return false
+		}
+		var alpha byte
+		var flags uint32
+		ret, _, _ := procGetLayeredWindowAttributes.Call(uintptr(hwnd), 0,
+			uintptr(unsafe.Pointer(&alpha)), uintptr(unsafe.Pointer(&flags)))
+		return ret != 0 && flags&uint32(LWA_ALPHA) != 0 && alpha == 0
+	default:
+		ret, _, _ := procIsWindowVisible.Call(uintptr(hwnd))
+		return ret == 0
+	}
+}
+
+func hideWindowVis(hwnd syscall.Handle) {
+	procShowWindow.Call(uintptr(hwnd), uintptr(SW_HIDE))
+}
+
+func showWindowVis(hwnd syscall.Handle) {
+	procShowWindow.Call(uintptr(hwnd), uintptr(SW_SHOW))
+}
+
+func minimizeWindow(hwnd syscall.Handle) {
+	procShowWindow.Call(uintptr(hwnd), uintptr(SW_SHOWMINNOACTIVE))
+}
+
+func restoreWindow(hwnd syscall.Handle) {
+	procShowWindow.Call(uintptr(hwnd), uintptr(SW_RESTORE))
+}
+
+// offscreenPosition returns a point just past the bottom right corner of
+// the virtual screen, which spans all monitors.
+func offscreenPosition() (int32, int32) {
+	metric := func(index int) int32 {
+		ret, _, _ := procGetSystemMetrics.Call(uintptr(index))
+		return int32(ret)
+	}
+	x := metric(SM_XVIRTUALSCREEN) + metric(SM_CXVIRTUALSCREEN)
+	y := metric(SM_YVIRTUALSCREEN) + metric(SM_CYVIRTUALSCREEN)
+	return x, y
+}
+
+func moveWindowOffscreen(hwnd syscall.Handle) error {
+	var rect struct {
+		left, top, right, bottom int32
+	}
+	ret, _, err := procGetWindowRect.Call(uintptr(hwnd), uintptr(unsafe.Pointer(&rect)))
+	if ret == 0 {
+		return fmt.Errorf("failed to get window rect: %v", err)
+	}
+
+	// An already hidden window keeps the position it was hidden from.
+	if !hasProp(hwnd, propOffscreen) {
+		setProp(hwnd, propOffscreenX, uintptr(rect.left))
+		setProp(hwnd, propOffscreenY, uintptr(rect.top))
+		setProp(hwnd, propOffscreen, 1)
+	}
+
+	x, y := offscreenPosition()
+	procSetWindowPos.Call(
+		uintptr(hwnd),
+		0,
+		uintptr(x),
+		uintptr(y),
+		0,
+		0,
+		uintptr(SWP_NOSIZE|SWP_NOZORDER|SWP_NOACTIVATE),
+	)
+	return nil
+}
+
+func moveWindowOnscreen(hwnd syscall.Handle) error {
+	_, ok := takeProp(hwnd, propOffscreen)
+	x, okX := takeProp(hwnd, propOffscreenX)
+	y, okY := takeProp(hwnd, propOffscreenY)
+	if !ok || !okX || !okY {
+		return fmt.Errorf("window was not hidden with -mode offscreen")
+	}
+
+	procSetWindowPos.Call(
+		uintptr(hwnd),
+		0,
+		uintptr(int32(x)),
+		uintptr(int32(y)),
+		0,
+		0,
+		uintptr(SWP_NOSIZE|SWP_NOZORDER|SWP_NOACTIVATE),
+	)
+	return nil
+}
+
+func makeWindowTransparent(hwnd syscall.Handle) error {
+	style, _, _ := procGetWindowLong.Call(uintptr(hwnd), uintptr(GWL_EXSTYLE))
+	if style&uintptr(WS_EX_LAYERED) == 0 {
+		setProp(hwnd, propLayered, 1)
+		procSetWindowLong.Call(uintptr(hwnd), uintptr(GWL_EXSTYLE), style|uintptr(WS_EX_LAYERED))
+	}
+
+	ret, _, err := procSetLayeredWindowAttributes.Call(uintptr(hwnd), 0, 0, uintptr(LWA_ALPHA))
+	if ret == 0 {
+		return fmt.Errorf("failed to set window opacity: %v", err)
+	}
+	return nil
+}
+
+func makeWindowOpaque(hwnd syscall.Handle) error {
+	ret, _, err := procSetLayeredWindowAttributes.Call(uintptr(hwnd), 0, 255, uintptr(LWA_ALPHA))
+	if ret == 0 {
+		return fmt.Errorf("failed to set window opacity: %v", err)
+	}
+
+	// Only drop the layered style if it was added when hiding the window.
+	if _, added := takeProp(hwnd, propLayered); added {
+		style, _, _ := procGetWindowLong.Call(uintptr(hwnd), uintptr(GWL_EXSTYLE))
+		procSetWindowLong.Call(uintptr(hwnd), uintptr(GWL_EXSTYLE), style&^uintptr(WS_EX_LAYERED))
+	}
+	return nil
+}
+
+func hideWithMode(hwnd syscall.Handle, mode string) error {
+	switch mode {
+	case modeIconify:
+		minimizeWindow(hwnd)
+	case modeOffscreen:
+		return moveWindowOffscreen(hwnd)
+	case modeOpacity:
+		return makeWindowTransparent(hwnd)
+	default:
+		hideWindowVis(hwnd)
+	}
+	return nil
+}
+
+func showWithMode(hwnd syscall.Handle, mode string) error {
+	switch mode {
+	case modeIconify:
+		restoreWindow(hwnd)
+	case modeOffscreen:
+		return moveWindowOnscreen(hwnd)
+	case modeOpacity:
+		return makeWindowOpaque(hwnd)
+	default:
+		showWindowVis(hwnd)
+	}
+	return nil
+}
+
+// Run is the whole of gwc-hide-vis (hide true) and gwc-show-vis (hide
+// false); the two differ only in which way they act without toggle.
+func Run(hide bool) {
+	action, modeHelp := "hide", "How to hide the window"
+	if !hide {
+		action, modeHelp = "show", "How the window was hidden"
+	}
+
+	var windowTitle, mode string
+	var targetFlags target.Flags
+	flag.StringVar(&windowTitle, "title", "", "Window title to find and "+action)
+	targetFlags.Register()
+	flag.StringVar(&mode, "mode", modeUnmap, modeHelp+": unmap, iconify, offscreen or opacity")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "Usage: gwc-"+action+"-vis -title TITLE [-mode MODE] [toggle]")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	toggle := false
+	switch {
+	case flag.NArg() == 0:
+	case flag.NArg() == 1 && flag.Arg(0) == "toggle":
+		toggle = true
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if !isValidMode(mode) {
+		fmt.Println("Unknown mode:", mode)
+		os.Exit(2)
+	}
+
+	hwnd, err := target.Window(windowTitle, targetFlags, findWindow)
+	if err != nil {
+		fmt.Println("Error finding window:", err)
+		os.Exit(1)
+	}
+
+	if toggle {
+		hide = !isHiddenWithMode(hwnd, mode)
+	}
+
+	if hide {
+		err = hideWithMode(hwnd, mode)
+	} else {
+		err = showWithMode(hwnd, mode)
+	}
+	if err != nil {
+		if hide {
+			fmt.Println("Error hiding window:", err)
+		} else {
+			fmt.Println("Error showing window:", err)
+		}
+		os.Exit(1)
+	}
+
+	if toggle {
+		if isHiddenWithMode(hwnd, mode) {
+			fmt.Println("Window is hidden.")
+		} else {
+			fmt.Println("Window is shown.")
+		}
+	}
+}
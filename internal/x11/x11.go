@@ -0,0 +1,428 @@
+//go:build linux
+// +build linux
+
+// Package x11 holds the X11 and EWMH helpers the gwctl commands share:
+// finding windows by title, reading properties and asking the window
+// manager for changes.
+package x11
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/xgb"
+	"github.com/BurntSushi/xgb/xproto"
+)
+
+// Actions of a _NET_WM_STATE client message.
+const (
+	StateRemove = 0
+	StateAdd    = 1
+	StateToggle = 2
+)
+
+// SourcePager is the source indication for client messages. EWMH asks
+// pagers and other tools acting for the user to send 2, which window
+// managers do not subject to focus-stealing prevention.
+const SourcePager = 2
+
+func InternAtom(conn *xgb.Conn, name string) (xproto.Atom, error) {
+	reply, err := xproto.InternAtom(conn, false, uint16(len(name)), name).Reply()
+	if err != nil {
+		return 0, err
+	}
+	return reply.Atom, nil
+}
+
+// WindowName returns _NET_WM_NAME, or WM_NAME if it is not set.
+func WindowName(conn *xgb.Conn, window xproto.Window) string {
+	if netWmName, err := InternAtom(conn, "_NET_WM_NAME"); err == nil {
+		reply, err := xproto.GetProperty(conn, false, window,
+			netWmName, xproto.GetPropertyTypeAny, 0, (1<<32)-1).Reply()
+		if err == nil && reply != nil && reply.ValueLen > 0 {
+			return string(reply.Value)
+		}
+	}
+
+	reply, err := xproto.GetProperty(conn, false, window,
+		xproto.AtomWmName, xproto.GetPropertyTypeAny, 0, (1<<32)-1).Reply()
+	if err == nil && reply != nil && reply.ValueLen > 0 {
+		return string(reply.Value)
+	}
+	return ""
+}
+
+// WindowClass returns the instance and class names from WM_CLASS.
+func WindowClass(conn *xgb.Conn, window xproto.Window) []string {
+	reply, err := xproto.GetProperty(conn, false, window,
+		xproto.AtomWmClass, xproto.AtomString, 0, (1<<32)-1).Reply()
+	if err != nil || reply == nil || reply.ValueLen == 0 {
+		return nil
+	}
+	return strings.Split(strings.TrimRight(string(reply.Value), "\x00"), "\x00")
+}
+
+// IsClientWindow reports whether the window is a top-level client window,
+// i.e. one the window manager manages, rather than a frame around it.
+func IsClientWindow(conn *xgb.Conn, window xproto.Window) bool {
+	wmState, err := InternAtom(conn, "WM_STATE")
+	if err != nil {
+		return false
+	}
+	reply, err := xproto.GetProperty(conn, false, window,
+		wmState, xproto.GetPropertyTypeAny, 0, 0).Reply()
+	return err == nil && reply != nil && reply.Format != 0
+}
+
+func findWindowRecursive(conn *xgb.Conn, parent xproto.Window, title string) (xproto.Window, error) {
+	if IsClientWindow(conn, parent) {
+		windowName := WindowName(conn, parent)
+		if strings.Contains(strings.ToLower(windowName), strings.ToLower(title)) {
+			return parent, nil
+		}
+	}
+
+	treeReply, err := xproto.QueryTree(conn, parent).Reply()
+	if err != nil {
+		return 0, err
+	}
+
+	for _, child := range treeReply.Children {
+		if found, err := findWindowRecursive(conn, child, title); err == nil && found != 0 {
+			return found, nil
+		}
+	}
+
+	return 0, nil
+}
+
+// FindWindow returns the first client window whose title contains title,
+// ignoring case.
+func FindWindow(conn *xgb.Conn, title string) (xproto.Window, error) {
+	root := xproto.Setup(conn).DefaultScreen(conn).Root
+	window, err := findWindowRecursive(conn, root, title)
+	if err != nil {
+		return 0, err
+	}
+	if window == 0 {
+		return 0, fmt.Errorf("no window with title containing '%s'", title)
+	}
+	return window, nil
+}
+
+// SendClientMessage sends an EWMH request about the window to the root
+// window, where the window manager picks it up.
+func SendClientMessage(conn *xgb.Conn, window xproto.Window, messageType string, data ...uint32) error {
+	atom, err := InternAtom(conn, messageType)
+	if err != nil {
+		return err
+	}
+
+	for len(data) < 5 {
+		data = append(data, 0)
+	}
+
+	root := xproto.Setup(conn).DefaultScreen(conn).Root
+	event := xproto.ClientMessageEvent{
+		Format: 32,
+		Window: window,
+		Type:   atom,
+		Data:   xproto.ClientMessageDataUnionData32New(data),
+	}
+	return xproto.SendEventChecked(conn, false, root,
+		xproto.EventMaskSubstructureNotify|xproto.EventMaskSubstructureRedirect,
+		string(event.Bytes())).Check()
+}
+
+// Cardinal reads the first value of a CARDINAL property. ok is false if
+// the property is not set.
+func Cardinal(conn *xgb.Conn, window xproto.Window, name string) (uint32, bool) {
+	atom, err := InternAtom(conn, name)
+	if err != nil {
+		return 0, false
+	}
+	reply, err := xproto.GetProperty(conn, false, window,
+		atom, xproto.AtomCardinal, 0, 1).Reply()
+	if err != nil || reply == nil || len(reply.Value) < 4 {
+		return 0, false
+	}
+	return xgb.Get32(reply.Value), true
+}
+
+// WMSupports reports whether the window manager lists the atom in
+// _NET_SUPPORTED.
+func WMSupports(conn *xgb.Conn, name string) bool {
+	supported, err := InternAtom(conn, "_NET_SUPPORTED")
+	if err != nil {
+		return false
+	}
+	atom, err := InternAtom(conn, name)
+	if err != nil {
+		return false
+	}
+
+	root := xproto.Setup(conn).DefaultScreen(conn).Root
+	reply, err := xproto.GetProperty(conn, false, root,
+		supported, xproto.AtomAtom, 0, (1<<32)-1).Reply()
+	if err != nil {
+		return false
+	}
+	for i := 0; i+4 <= len(reply.Value); i += 4 {
+		if xproto.Atom(xgb.Get32(reply.Value[i:])) == atom {
+			return true
+		}
+	}
+	return false
+}
+
+// ActiveWindow returns _NET_ACTIVE_WINDOW, or 0 if the window manager does
+// not report one.
+func ActiveWindow(conn *xgb.Conn) xproto.Window {
+	atom, err := InternAtom(conn, "_NET_ACTIVE_WINDOW")
+	if err != nil {
+		return 0
+	}
+	root := xproto.Setup(conn).DefaultScreen(conn).Root
+	reply, err := xproto.GetProperty(conn, false, root,
+		atom, xproto.AtomWindow, 0, 1).Reply()
+	if err != nil || len(reply.Value) < 4 {
+		return 0
+	}
+	return xproto.Window(xgb.Get32(reply.Value))
+}
+
+// ServerTime returns a current X server timestamp by touching a property
+// on a helper window and reading the time from the resulting
+// PropertyNotify. Window managers with focus-stealing prevention refuse
+// activation requests without a valid timestamp.
+func ServerTime(conn *xgb.Conn) xproto.Timestamp {
+	screen := xproto.Setup(conn).DefaultScreen(conn)
+	win, err := xproto.NewWindowId(conn)
+	if err != nil {
+		return xproto.TimeCurrentTime
+	}
+	err = xproto.CreateWindowChecked(conn, 0, win, screen.Root, -1, -1, 1, 1, 0,
+		xproto.WindowClassInputOnly, screen.RootVisual,
+		xproto.CwEventMask, []uint32{xproto.EventMaskPropertyChange}).Check()
+	if err != nil {
+		return xproto.TimeCurrentTime
+	}
+	defer xproto.DestroyWindow(conn, win)
+
+	atom, err := InternAtom(conn, "_GWCTL_TIMESTAMP")
+	if err != nil {
+		return xproto.TimeCurrentTime
+	}
+	xproto.ChangeProperty(conn, xproto.PropModeAppend, win, atom, xproto.AtomString, 8, 0, nil)
+
+	timeCh := make(chan xproto.Timestamp, 1)
+	go func() {
+		for {
+			ev, err := conn.WaitForEvent()
+			if ev == nil && err == nil {
+				return
+			}
+			if e, ok := ev.(xproto.PropertyNotifyEvent); ok && e.Window == win {
+				timeCh <- e.Time
+				return
+			}
+		}
+	}()
+
+	select {
+	case t := <-timeCh:
+		return t
+	case <-time.After(200 * time.Millisecond):
+		return xproto.TimeCurrentTime
+	}
+}
+
+// HasFocus reports whether the window, or a window inside it, has the
+// keyboard focus.
+func HasFocus(conn *xgb.Conn, window xproto.Window) bool {
+	if ActiveWindow(conn) == window {
+		return true
+	}
+
+	reply, err := xproto.GetInputFocus(conn).Reply()
+	if err != nil {
+		return false
+	}
+	root := xproto.Setup(conn).DefaultScreen(conn).Root
+	for focus := reply.Focus; focus != 0 && focus != root; {
+		if focus == window {
+			return true
+		}
+		tree, err := xproto.QueryTree(conn, focus).Reply()
+		if err != nil {
+			return false
+		}
+		focus = tree.Parent
+	}
+	return false
+}
+
+// WaitForFocus waits until the window has the focus, or until timeout,
+// and reports whether it got there.
+func WaitForFocus(conn *xgb.Conn, window xproto.Window, timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for {
+		if HasFocus(conn, window) {
+			return true
+		}
+		if !time.Now().Before(deadline) {
+			return false
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+// ActivateWindow asks the window manager to activate the window, which
+// also deiconifies it and switches to its desktop. If the window manager
+// does not support that, or does not comply, the window is mapped, raised
+// and focused directly.
+func ActivateWindow(conn *xgb.Conn, window xproto.Window, timeout time.Duration) bool {
+	t := ServerTime(conn)
+
+	if WMSupports(conn, "_NET_ACTIVE_WINDOW") {
+		err := SendClientMessage(conn, window, "_NET_ACTIVE_WINDOW",
+			SourcePager, uint32(t), uint32(ActiveWindow(conn)))
+		if err == nil && WaitForFocus(conn, window, timeout) {
+			return true
+		}
+	}
+
+	xproto.MapWindow(conn, window)
+	xproto.ConfigureWindow(conn, window, xproto.ConfigWindowStackMode, []uint32{xproto.StackModeAbove})
+	err := xproto.SetInputFocusChecked(conn, xproto.InputFocusParent, window, t).Check()
+	if err != nil {
+		return false
+	}
+	return WaitForFocus(conn, window, timeout)
+}
+
+// RestackWindow changes the stacking order of window relative to sibling,
+// or to all windows if sibling is 0. Window managers that do not support
+// _NET_RESTACK_WINDOW get a plain configure request, which they handle
+// the same way for top-level windows.
+func RestackWindow(conn *xgb.Conn, window, sibling xproto.Window, mode byte) error {
+	if WMSupports(conn, "_NET_RESTACK_WINDOW") {
+		return SendClientMessage(conn, window, "_NET_RESTACK_WINDOW", SourcePager, uint32(sibling), uint32(mode))
+	}
+
+	if sibling == 0 {
+		return xproto.ConfigureWindowChecked(conn, window,
+			xproto.ConfigWindowStackMode, []uint32{uint32(mode)}).Check()
+	}
+	return xproto.ConfigureWindowChecked(conn, window,
+		xproto.ConfigWindowSibling|xproto.ConfigWindowStackMode,
+		[]uint32{uint32(sibling), uint32(mode)}).Check()
+}
+
+// WindowStates returns the names of the window's _NET_WM_STATE entries.
+func WindowStates(conn *xgb.Conn, window xproto.Window) (map[string]bool, error) {
+	atom, err := InternAtom(conn, "_NET_WM_STATE")
+	if err != nil {
+		return nil, err
+	}
+	reply, err := xproto.GetProperty(conn, false, window,
+		atom, xproto.AtomAtom, 0, (1<<32)-1).Reply()
+	if err != nil {
+		return nil, err
+	}
+
+	states := make(map[string]bool)
+	for i := 0; i+4 <= len(reply.Value); i += 4 {
+		nameReply, err := xproto.GetAtomName(conn, xproto.Atom(xgb.Get32(reply.Value[i:]))).Reply()
+		if err == nil {
+			states[nameReply.Name] = true
+		}
+	}
+	return states, nil
+}
+
+// HasWindowState reports whether the window's _NET_WM_STATE contains the
+// named state.
+func HasWindowState(conn *xgb.Conn, window xproto.Window, name string) bool {
+	atom, err := InternAtom(conn, "_NET_WM_STATE")
+	if err != nil {
+		return false
+	}
+	want, err := InternAtom(conn, name)
+	if err != nil {
+		return false
+	}
+	reply, err := xproto.GetProperty(conn, false, window,
+		atom, xproto.AtomAtom, 0, (1<<32)-1).Reply()
+	if err != nil {
+		return false
+	}
+	for i := 0; i+4 <= len(reply.Value); i += 4 {
+		if xproto.Atom(xgb.Get32(reply.Value[i:])) == want {
+			return true
+		}
+	}
+	return false
+}
+
+// SetWindowStates adds, removes or toggles _NET_WM_STATE entries. EWMH
+// allows two states per message; they are sent one at a time for
+// simplicity.
+func SetWindowStates(conn *xgb.Conn, window xproto.Window, action uint32, states ...string) error {
+	for _, name := range states {
+		atom, err := InternAtom(conn, name)
+		if err != nil {
+			return err
+		}
+		if err := SendClientMessage(conn, window, "_NET_WM_STATE", action, uint32(atom), 0, SourcePager); err != nil {
+			return fmt.Errorf("failed to change %s: %v", name, err)
+		}
+	}
+	return nil
+}
+
+// WaitForState waits until the window manager has applied the wanted
+// state, or until timeout, and returns the state the window ended up in.
+func WaitForState(conn *xgb.Conn, window xproto.Window, state string, want bool, timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for {
+		states, err := WindowStates(conn, window)
+		if err != nil {
+			return !want
+		}
+		if states[state] == want || !time.Now().Before(deadline) {
+			return states[state]
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+// ParseStateAction turns the on, off or toggle argument of the state
+// commands into a _NET_WM_STATE action.
+func ParseStateAction(arg string) (uint32, bool) {
+	switch arg {
+	case "on":
+		return StateAdd, true
+	case "off":
+		return StateRemove, true
+	case "toggle":
+		return StateToggle, true
+	}
+	return 0, false
+}
+
+// ChangeState applies a _NET_WM_STATE action to one state, waits for the
+// window manager to apply it, and returns whether the window ends up with
+// the state.
+func ChangeState(conn *xgb.Conn, window xproto.Window, action uint32, state string, timeout time.Duration) (bool, error) {
+	before, err := WindowStates(conn, window)
+	if err != nil {
+		return false, fmt.Errorf("failed to read window state: %v", err)
+	}
+	if err := SetWindowStates(conn, window, action, state); err != nil {
+		return before[state], err
+	}
+	want := action == StateAdd || action == StateToggle && !before[state]
+	return WaitForState(conn, window, state, want, timeout), nil
+}